@@ -0,0 +1,173 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	sortpkg "sort"
+	"sync"
+)
+
+// anomalySamples is the number of points drawn by AnomalyScore to estimate
+// the distribution of densities.
+const anomalySamples = 500
+
+// anomalyRefresh is the fraction by which the count of the histogram of a
+// Detector may change before its reference densities are drawn again.
+const anomalyRefresh = 0.1
+
+// AnomalyScore returns the estimated fraction of points in a denser region
+// than x: a score of 0.99 means x is less likely than 99% of the
+// distribution, and points far outside all bins score 1. The distribution of
+// densities is estimated from a fixed pseudo-random sample of the bins, which
+// costs anomalySamples density evaluations per call; a Detector reuses the
+// sample between points instead. AnomalyScore returns -1 on a dimension
+// mismatch and 0 for an empty histogram.
+//
+// Unlike PDF, which spreads every bin uniformly over its min/max box, the
+// scores model every bin as a Gaussian with its mean and variance. The boxes
+// of compressed bins leave gaps between them where PDF is zero, so under the
+// box model a typical point falling in a gap would score as high as one far
+// outside the data; the Gaussians cover the gaps and fall off smoothly with
+// the distance to the bins.
+func AnomalyScore(h Histogram, x []float64) float64 {
+	return anomalyScore(h, x, referenceDensities)
+}
+
+// anomalyScore returns the AnomalyScore of x, ranking its density among the
+// sorted densities returned by reference for the bins of h.
+func anomalyScore(h Histogram, x []float64, reference func(bins []bin, w []float64, total float64) []float64) float64 {
+	b, ok := h.(boxer)
+	if !ok || len(x) != h.Dimension() {
+		return -1
+	}
+	bins := b.boxes()
+	total := 0.0
+	for i := range bins {
+		total += bins[i].count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	w := widths(bins, h.Dimension())
+	densities := reference(bins, w, total)
+	density := mixture(bins, w, x)
+
+	// Count the reference densities above density, and half of those equal.
+	lo := sortpkg.SearchFloat64s(densities, density)
+	hi := sortpkg.Search(len(densities), func(i int) bool { return densities[i] > density })
+	return (float64(len(densities)-hi) + float64(hi-lo)/2) / float64(len(densities))
+}
+
+// referenceDensities returns the sorted densities of anomalySamples points
+// drawn from the bins as Gaussians.
+func referenceDensities(bins []bin, w []float64, total float64) []float64 {
+	r := rand.New(rand.NewSource(1))
+	densities := make([]float64, anomalySamples)
+	y := make([]float64, len(w))
+	for i := range densities {
+		u := r.Float64() * total
+		k := 0
+		for k < len(bins)-1 && u >= bins[k].count {
+			u -= bins[k].count
+			k++
+		}
+		for j := range y {
+			y[j] = bins[k].vec.Value(j) + r.NormFloat64()*math.Sqrt(variance(bins[k], j, w))
+		}
+		densities[i] = mixture(bins, w, y)
+	}
+	sortpkg.Float64s(densities)
+	return densities
+}
+
+// mixture returns the density at x of the bins as Gaussians.
+func mixture(bins []bin, w []float64, x []float64) float64 {
+	sum, total := 0.0, 0.0
+	for i := range bins {
+		total += bins[i].count
+		l := 0.0
+		for j := range x {
+			v := variance(bins[i], j, w)
+			l -= square(x[j]-bins[i].vec.Value(j))/(2*v) + math.Log(2*math.Pi*v)/2
+		}
+		sum += bins[i].count * math.Exp(l)
+	}
+	return sum / total
+}
+
+// variance returns the variance of b in dimension j, or that of a uniform
+// distribution over the width w[j] if it is zero.
+func variance(b bin, j int, w []float64) float64 {
+	if v := b.variance.Value(j); v > 0 {
+		return v
+	}
+	return w[j] * w[j] / 12
+}
+
+// Anomaly is a point whose score exceeded the threshold of a Detector.
+type Anomaly struct {
+	Values []float64
+	Score  float64
+
+	// Count is the number of points added before this one.
+	Count float64
+}
+
+// Detector scores every point against a histogram before adding it, and
+// reports points scoring above a threshold. The reference densities of the
+// scores are only drawn again once the count of the histogram has changed by
+// anomalyRefresh, so adding a point costs a single density evaluation in
+// between. It is safe for concurrent use.
+type Detector struct {
+	mu        sync.Mutex
+	h         Histogram
+	threshold float64
+	warmup    float64
+	report    func(Anomaly)
+
+	densities []float64
+	sampled   float64
+}
+
+// NewDetector returns a detector adding points to h and calling report for
+// points with an AnomalyScore above threshold, e.g. 0.99 for the least likely
+// 1%. Points are only reported once h holds at least warmup points, since
+// scores of sparse histograms are unreliable.
+func NewDetector(h Histogram, threshold float64, warmup float64, report func(Anomaly)) *Detector {
+	return &Detector{h: h, threshold: threshold, warmup: warmup, report: report}
+}
+
+// Add scores values, adds them to the histogram, and returns the score and
+// whether it was reported as an anomaly.
+func (d *Detector) Add(values []float64) (score float64, anomalous bool) {
+	d.mu.Lock()
+	count := d.h.Count()
+	score = anomalyScore(d.h, values, d.reference)
+	d.h.Add(values)
+	d.mu.Unlock()
+
+	anomalous = count >= d.warmup && score > d.threshold
+	if anomalous && d.report != nil {
+		v := make([]float64, len(values))
+		copy(v, values)
+		d.report(Anomaly{Values: v, Score: score, Count: count})
+	}
+	return
+}
+
+// reference returns the cached reference densities, drawing them again if the
+// total has changed by more than anomalyRefresh since they were drawn.
+func (d *Detector) reference(bins []bin, w []float64, total float64) []float64 {
+	if d.densities == nil || math.Abs(total-d.sampled) > anomalyRefresh*d.sampled {
+		d.densities = referenceDensities(bins, w, total)
+		d.sampled = total
+	}
+	return d.densities
+}
+
+// Histogram returns the histogram of the detector, which must not be used
+// concurrently with Add.
+func (d *Detector) Histogram() Histogram {
+	return d.h
+}
@@ -0,0 +1,119 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAnomalyScore(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h := NewHistogram(32, 2)
+	for i := 0; i < 5000; i++ {
+		h.Add([]float64{r.NormFloat64(), r.NormFloat64()})
+	}
+
+	if s := AnomalyScore(h, []float64{0, 0}); s > 0.3 {
+		t.Errorf("Score of the mode %v", s)
+	}
+	if s := AnomalyScore(h, []float64{8, 8}); s < 0.99 {
+		t.Errorf("Score of an outlier %v", s)
+	}
+	if s := AnomalyScore(h, []float64{0}); s != -1 {
+		t.Errorf("Score of dimension 1 %v != -1", s)
+	}
+	if s := AnomalyScore(NewHistogram(32, 2), []float64{0, 0}); s != 0 {
+		t.Errorf("Score in empty histogram %v != 0", s)
+	}
+
+	// Scores of new points from the same distribution are roughly uniform.
+	above := 0
+	for i := 0; i < 1000; i++ {
+		if AnomalyScore(h, []float64{r.NormFloat64(), r.NormFloat64()}) > 0.9 {
+			above++
+		}
+	}
+	if above < 30 || above > 200 {
+		t.Errorf("%d of 1000 points score above 0.9", above)
+	}
+}
+
+func TestDetector(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	anomalies := make([]Anomaly, 0)
+	d := NewDetector(NewHistogram(32, 2), 0.99, 200, func(a Anomaly) {
+		anomalies = append(anomalies, a)
+	})
+
+	// Nothing is reported during warm-up, however unusual.
+	for i := 0; i < 200; i++ {
+		x := []float64{r.NormFloat64(), r.NormFloat64()}
+		if i%50 == 49 {
+			x = []float64{20, -20}
+		}
+		d.Add(x)
+	}
+	if len(anomalies) != 0 {
+		t.Errorf("%d anomalies reported during warm-up", len(anomalies))
+	}
+
+	outliers := 0
+	for i := 0; i < 2000; i++ {
+		x := []float64{r.NormFloat64(), r.NormFloat64()}
+		if i%100 == 99 {
+			// Outliers in different directions, so they do not form a cluster.
+			a := r.Float64() * 2 * math.Pi
+			x = []float64{10 * math.Cos(a), 10 * math.Sin(a)}
+		}
+		score, anomalous := d.Add(x)
+		if i%100 == 99 {
+			if !anomalous {
+				t.Errorf("Outlier %v not reported, score %v", x, score)
+			}
+			outliers++
+		}
+	}
+	if d.Histogram().Count() != 2200 {
+		t.Errorf("Count %v != 2200", d.Histogram().Count())
+	}
+	if len(anomalies) < outliers || len(anomalies) > outliers+100 {
+		t.Errorf("%d anomalies reported for %d outliers", len(anomalies), outliers)
+	}
+	for _, a := range anomalies {
+		if a.Score <= 0.99 || a.Count < 200 || len(a.Values) != 2 {
+			t.Errorf("Anomaly %v below threshold or during warm-up", a)
+		}
+	}
+}
+
+func TestDetectorReference(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	h := NewHistogram(32, 2)
+	for i := 0; i < 1000; i++ {
+		h.Add([]float64{r.NormFloat64(), r.NormFloat64()})
+	}
+	d := NewDetector(h, 0.99, 0, nil)
+
+	// Scores with the cached reference densities stay close to those drawn
+	// for every point, which are only drawn again every 10% of growth.
+	draws, sum := 0, 0.0
+	for i := 0; i < 2000; i++ {
+		x := []float64{r.NormFloat64(), r.NormFloat64()}
+		exact := AnomalyScore(h, x)
+		sampled := d.sampled
+		score, _ := d.Add(x)
+		if d.sampled != sampled {
+			draws++
+		}
+		sum += math.Abs(score - exact)
+		if math.Abs(score-exact) > 0.1 {
+			t.Errorf("Detector score %v, AnomalyScore %v", score, exact)
+		}
+	}
+	if sum/2000 > 0.03 {
+		t.Errorf("Mean difference of detector scores %v", sum/2000)
+	}
+	if draws > 15 {
+		t.Errorf("Reference densities drawn %d times for 2000 points", draws)
+	}
+}
@@ -0,0 +1,342 @@
+// Command histeval measures the accuracy of histogram CDF estimates against
+// the exact empirical CDF of a CSV dataset.
+//
+// For every combination of dimension and bin count, the first d columns of
+// the dataset are streamed into a histogram and the CDF is evaluated at
+// mean + k*sd for every requested k, next to the exact empirical CDF of the
+// dataset at the same points.
+//
+//	histeval -dims 1,2,3,4,5 -bins 1024 -points -2,-1,0,1,2 data.csv
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"histogram"
+)
+
+var (
+	binsFlag   = flag.String("bins", "32,64,128,256,512,1024", "comma separated list of bin counts")
+	dimsFlag   = flag.String("dims", "1", "comma separated list of dimensions, using the first d columns")
+	pointsFlag = flag.String("points", "-2,-1,0,1,2", "comma separated CDF points, in standard deviations from the mean")
+	format     = flag.String("format", "table", "output format: table or csv")
+	header     = flag.Bool("header", false, "skip the first row of the input")
+	delimiter  = flag.String("delimiter", ",", "field delimiter of the input")
+)
+
+type result struct {
+	dimension int
+	bins      int
+	count     float64
+	build     time.Duration
+	estimates []float64
+	exact     []float64
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: histeval [flags] [file ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	bins, err := parseInts(*binsFlag)
+	if err != nil {
+		fatal("invalid -bins: %v", err)
+	}
+	for _, b := range bins {
+		if b < 1 {
+			usage("-bins must be at least 1, not %d", b)
+		}
+	}
+	dims, err := parseInts(*dimsFlag)
+	if err != nil {
+		fatal("invalid -dims: %v", err)
+	}
+	for _, d := range dims {
+		if d < 1 {
+			usage("-dims must be at least 1, not %d", d)
+		}
+	}
+	points, err := parseFloats(*pointsFlag)
+	if err != nil {
+		fatal("invalid -points: %v", err)
+	}
+	if *format != "table" && *format != "csv" {
+		fatal("invalid -format: %q", *format)
+	}
+	if utf8.RuneCountInString(*delimiter) != 1 {
+		usage("-delimiter must be a single character, not %q", *delimiter)
+	}
+
+	data, err := readInput(flag.Args())
+	if err != nil {
+		fatal("%v", err)
+	}
+	if len(data) == 0 {
+		fatal("no data")
+	}
+
+	var results []result
+	for _, d := range dims {
+		if d < 1 || d > len(data[0]) {
+			fatal("dimension %d out of range, input has %d columns", d, len(data[0]))
+		}
+		sample := columns(data, d)
+		for _, b := range bins {
+			results = append(results, evaluate(sample, b, points))
+		}
+	}
+
+	if *format == "csv" {
+		err = writeCSV(os.Stdout, points, results)
+	} else {
+		err = writeTable(os.Stdout, points, results)
+	}
+	if err != nil {
+		fatal("%v", err)
+	}
+}
+
+func evaluate(sample [][]float64, b int, points []float64) result {
+	d := len(sample[0])
+
+	start := time.Now()
+	h := histogram.NewHistogram(b, d)
+	for _, values := range sample {
+		h.Add(values)
+	}
+	build := time.Since(start)
+
+	mean, sd := moments(sample)
+	r := result{dimension: d, bins: b, count: h.Count(), build: build}
+	for _, k := range points {
+		x := make([]float64, d)
+		for i := range x {
+			x[i] = mean[i] + k*sd[i]
+		}
+		r.estimates = append(r.estimates, h.CDF(x))
+		r.exact = append(r.exact, exactCDF(sample, x))
+	}
+	return r
+}
+
+// errors returns the maximum, mean absolute and root mean square error of the
+// estimates.
+func (r result) errors() (maxerr, meanerr, rmse float64) {
+	for i := range r.estimates {
+		e := math.Abs(r.estimates[i] - r.exact[i])
+		maxerr = math.Max(maxerr, e)
+		meanerr += e
+		rmse += e * e
+	}
+	n := float64(len(r.estimates))
+	return maxerr, meanerr / n, math.Sqrt(rmse / n)
+}
+
+func moments(sample [][]float64) (mean, sd []float64) {
+	d := len(sample[0])
+	mean = make([]float64, d)
+	sd = make([]float64, d)
+	for _, values := range sample {
+		for i := range mean {
+			mean[i] += values[i]
+		}
+	}
+	for i := range mean {
+		mean[i] /= float64(len(sample))
+	}
+	for _, values := range sample {
+		for i := range sd {
+			sd[i] += (values[i] - mean[i]) * (values[i] - mean[i])
+		}
+	}
+	for i := range sd {
+		sd[i] = math.Sqrt(sd[i] / float64(len(sample)))
+	}
+	return
+}
+
+func exactCDF(sample [][]float64, x []float64) float64 {
+	sum := 0.0
+	for _, values := range sample {
+		below := true
+		for i := range x {
+			if values[i] > x[i] {
+				below = false
+				break
+			}
+		}
+		if below {
+			sum++
+		}
+	}
+	return sum / float64(len(sample))
+}
+
+func columns(data [][]float64, d int) [][]float64 {
+	r := make([][]float64, len(data))
+	for i := range data {
+		r[i] = data[i][:d:d]
+	}
+	return r
+}
+
+func writeTable(w io.Writer, points []float64, results []result) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprint(tw, "DIMENSION\tBINS\tCOUNT\tBUILD")
+	for _, k := range points {
+		fmt.Fprintf(tw, "\tCDF(%s)\tEXACT", pointName(k))
+	}
+	fmt.Fprintln(tw, "\tMAX ERR\tMEAN ERR\tRMSE")
+
+	for _, r := range results {
+		fmt.Fprintf(tw, "%d\t%d\t%v\t%v", r.dimension, r.bins, r.count, r.build.Round(time.Millisecond))
+		for i := range points {
+			fmt.Fprintf(tw, "\t%.6f\t%.6f", r.estimates[i], r.exact[i])
+		}
+		maxerr, meanerr, rmse := r.errors()
+		fmt.Fprintf(tw, "\t%.6f\t%.6f\t%.6f\n", maxerr, meanerr, rmse)
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, points []float64, results []result) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"dimension", "bins", "count", "build_seconds", "point", "estimate", "exact", "error"})
+	for _, r := range results {
+		for i, k := range points {
+			cw.Write([]string{
+				strconv.Itoa(r.dimension),
+				strconv.Itoa(r.bins),
+				formatFloat(r.count),
+				formatFloat(r.build.Seconds()),
+				pointName(k),
+				formatFloat(r.estimates[i]),
+				formatFloat(r.exact[i]),
+				formatFloat(r.estimates[i] - r.exact[i]),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func pointName(k float64) string {
+	switch {
+	case k == 0:
+		return "MEAN"
+	case k == 1:
+		return "MEAN + SD"
+	case k == -1:
+		return "MEAN - SD"
+	case k > 0:
+		return "MEAN + " + formatFloat(k) + "SD"
+	default:
+		return "MEAN - " + formatFloat(-k) + "SD"
+	}
+}
+
+func readInput(files []string) ([][]float64, error) {
+	if len(files) == 0 {
+		return readCSV(os.Stdin, "stdin")
+	}
+
+	var data [][]float64
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := readCSV(f, name)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		// The reader checks the column count within a file, not across files.
+		if len(data) > 0 && len(rows) > 0 && len(rows[0]) != len(data[0]) {
+			return nil, fmt.Errorf("%s: %d columns, expected %d", name, len(rows[0]), len(data[0]))
+		}
+		data = append(data, rows...)
+	}
+	return data, nil
+}
+
+func readCSV(r io.Reader, name string) ([][]float64, error) {
+	cr := csv.NewReader(r)
+	cr.Comma, _ = utf8.DecodeRuneInString(*delimiter)
+	cr.TrimLeadingSpace = true
+
+	var data [][]float64
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && *header {
+			continue
+		}
+
+		values := make([]float64, len(record))
+		for i, field := range record {
+			values[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", name, line, err)
+			}
+		}
+		data = append(data, values)
+	}
+}
+
+func parseInts(s string) ([]int, error) {
+	var r []int
+	for _, field := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, n)
+	}
+	return r, nil
+}
+
+func parseFloats(s string) ([]float64, error) {
+	var r []float64
+	for _, field := range strings.Split(s, ",") {
+		x, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, x)
+	}
+	return r, nil
+}
+
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// usage reports an invalid flag with the usage message and exits.
+func usage(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "histeval: "+format+"\n", args...)
+	flag.Usage()
+	os.Exit(2)
+}
+
+func fatal(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "histeval: "+format+"\n", args...)
+	os.Exit(2)
+}
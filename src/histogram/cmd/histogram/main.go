@@ -0,0 +1,285 @@
+// Command histogram summarises delimited numeric data read from stdin or
+// files. Selected columns form the dimensions of a streaming histogram, which
+// can be saved and reloaded to answer further queries.
+//
+//	histogram -columns 2,3 -quantiles 0.5,0.99 -cdf 10,20 < data.csv
+//	histogram -columns 2,3 -save state.json data.csv
+//	histogram -load state.json -cdf 10,20
+//	histogram -load state.json -bars 1 -heatmap 1,2
+//	histogram -load state.json -svg plot.svg -boxes
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"histogram"
+)
+
+var (
+	bins          = flag.Int("bins", 64, "maximum number of bins")
+	columnsFlag   = flag.String("columns", "", "comma separated 1-based columns to use as dimensions (default all)")
+	delimiter     = flag.String("delimiter", ",", "field delimiter of the input")
+	header        = flag.Bool("header", false, "skip the first row of every input")
+	quantilesFlag = flag.String("quantiles", "0.5,0.9,0.99", "comma separated quantiles to print, empty for none")
+	load          = flag.String("load", "", "load histogram state from `file` before reading input")
+	save          = flag.String("save", "", "save histogram state to `file` after reading input")
+	bars          = flag.Int("bars", 0, "draw a bar chart of the 1-based `dimension`")
+	heatmap       = flag.String("heatmap", "", "draw a heatmap of the 1-based dimensions `x,y`")
+	width         = flag.Int("width", 60, "width of charts in characters")
+	height        = flag.Int("height", 20, "height of charts in rows")
+	svg           = flag.String("svg", "", "write a scatter matrix plot to `file`")
+	boxes         = flag.Bool("boxes", false, "overlay bin boxes on the -svg plot")
+	points        pointList
+)
+
+func init() {
+	flag.Var(&points, "cdf", "comma separated point to evaluate the CDF at (repeatable)")
+}
+
+type pointList [][]float64
+
+func (p *pointList) String() string {
+	return fmt.Sprint(*p)
+}
+
+func (p *pointList) Set(s string) error {
+	x, err := parseFloats(s)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, x)
+	return nil
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: histogram [flags] [file ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	columns, err := parseColumns(*columnsFlag)
+	if err != nil {
+		usage("invalid -columns: %v", err)
+	}
+	var quantiles []float64
+	if strings.TrimSpace(*quantilesFlag) != "" {
+		if quantiles, err = parseFloats(*quantilesFlag); err != nil {
+			usage("invalid -quantiles: %v", err)
+		}
+	}
+	for _, q := range quantiles {
+		if !(q >= 0 && q <= 1) {
+			usage("-quantiles must lie in [0, 1], not %v", q)
+		}
+	}
+	if *bins < 1 {
+		usage("-bins must be at least 1, not %d", *bins)
+	}
+	if utf8.RuneCountInString(*delimiter) != 1 {
+		usage("-delimiter must be a single character, not %q", *delimiter)
+	}
+
+	var h histogram.Histogram
+	if *load != "" {
+		data, err := os.ReadFile(*load)
+		if err != nil {
+			fatal("%v", err)
+		}
+		if h, err = histogram.Unmarshal(data); err != nil {
+			fatal("%s: %v", *load, err)
+		}
+		if columns != nil && len(columns) != h.Dimension() {
+			fatal("%d columns selected, loaded histogram has dimension %d", len(columns), h.Dimension())
+		}
+	}
+
+	files := flag.Args()
+	if len(files) == 0 && *load == "" {
+		files = []string{"-"}
+	}
+	for _, name := range files {
+		if h, err = readFile(h, name, columns); err != nil {
+			fatal("%v", err)
+		}
+	}
+	if h == nil {
+		fatal("no data")
+	}
+
+	if *save != "" {
+		data, err := json.Marshal(h)
+		if err != nil {
+			fatal("%v", err)
+		}
+		if err := os.WriteFile(*save, data, 0644); err != nil {
+			fatal("%v", err)
+		}
+	}
+
+	if *svg != "" {
+		f, err := os.Create(*svg)
+		if err != nil {
+			fatal("%v", err)
+		}
+		err = histogram.WriteSVG(f, h, histogram.SVGOptions{CDF: true, Boxes: *boxes})
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			fatal("%v", err)
+		}
+	}
+
+	if err := summarise(os.Stdout, h, quantiles, points); err != nil {
+		fatal("%v", err)
+	}
+
+	if *bars != 0 {
+		if *bars < 1 || *bars > h.Dimension() {
+			fatal("-bars dimension %d out of range", *bars)
+		}
+		fmt.Print("\n", histogram.Bars(h, *bars-1, *height, *width))
+	}
+	if *heatmap != "" {
+		dims, err := parseColumns(*heatmap)
+		if err != nil || len(dims) != 2 || dims[0] >= h.Dimension() || dims[1] >= h.Dimension() || dims[0] == dims[1] {
+			fatal("invalid -heatmap %q", *heatmap)
+		}
+		fmt.Print("\n", histogram.Heatmap(h, dims[0], dims[1], *height, *width))
+	}
+}
+
+func summarise(w io.Writer, h histogram.Histogram, quantiles []float64, points [][]float64) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "COUNT\t%v\n", h.Count())
+	fmt.Fprintf(tw, "MEAN\t%v\n", h.Mean())
+	fmt.Fprintf(tw, "VARIANCE\t%v\n", h.Variance())
+	fmt.Fprintf(tw, "MIN\t%v\n", h.Min())
+	fmt.Fprintf(tw, "MAX\t%v\n", h.Max())
+	for _, q := range quantiles {
+		fmt.Fprintf(tw, "QUANTILE(%v)\t%v\n", q, histogram.MarginalQuantile(h, q))
+	}
+	for _, x := range points {
+		if len(x) != h.Dimension() {
+			return fmt.Errorf("CDF point %v has dimension %d, expected %d", x, len(x), h.Dimension())
+		}
+		fmt.Fprintf(tw, "CDF(%v)\t%v\n", x, h.CDF(x))
+	}
+	return tw.Flush()
+}
+
+// readFile adds the rows of the named file, or stdin for "-", to h. A nil h is
+// created with the dimension of the first row.
+func readFile(h histogram.Histogram, name string, columns []int) (histogram.Histogram, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return h, err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		name = "stdin"
+	}
+
+	cr := csv.NewReader(r)
+	cr.Comma, _ = utf8.DecodeRuneInString(*delimiter)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return h, nil
+		}
+		if err != nil {
+			return h, err
+		}
+		if line == 1 && *header {
+			continue
+		}
+
+		selected := columns
+		if selected == nil {
+			selected = make([]int, len(record))
+			for i := range selected {
+				selected[i] = i
+			}
+		}
+
+		values := make([]float64, len(selected))
+		for i, c := range selected {
+			if c >= len(record) {
+				return h, fmt.Errorf("%s:%d: missing column %d", name, line, c+1)
+			}
+			values[i], err = strconv.ParseFloat(strings.TrimSpace(record[c]), 64)
+			if err != nil {
+				return h, fmt.Errorf("%s:%d: %v", name, line, err)
+			}
+		}
+
+		if h == nil {
+			h = histogram.NewHistogram(*bins, len(values))
+		}
+		if len(values) != h.Dimension() {
+			return h, fmt.Errorf("%s:%d: row has %d columns, expected %d", name, line, len(values), h.Dimension())
+		}
+		h.Add(values)
+	}
+}
+
+// parseColumns converts 1-based column numbers to indices. An empty string
+// selects all columns and returns nil.
+func parseColumns(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var r []int
+	for _, field := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 {
+			return nil, fmt.Errorf("column %d out of range", n)
+		}
+		r = append(r, n-1)
+	}
+	return r, nil
+}
+
+func parseFloats(s string) ([]float64, error) {
+	var r []float64
+	for _, field := range strings.Split(s, ",") {
+		x, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, x)
+	}
+	return r, nil
+}
+
+// usage reports an invalid flag with the usage message and exits.
+func usage(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "histogram: "+format+"\n", args...)
+	flag.Usage()
+	os.Exit(2)
+}
+
+func fatal(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "histogram: "+format+"\n", args...)
+	os.Exit(2)
+}
@@ -0,0 +1,59 @@
+package histogram
+
+// Condition returns the histogram of the points of h within the box (lo, hi],
+// such as the latency of requests larger than 1MB with lo set to 1MB and
+// infinite bounds elsewhere. Bins straddling the box are scaled by the
+// fraction inside it and clipped to it, assuming points are uniform within
+// them, like Probability. The result has the dimension of h, and is exact for
+// exact histograms and otherwise a native histogram with as many bins as h.
+// Condition returns nil on a dimension mismatch.
+func Condition(h Histogram, lo, hi []float64) Histogram {
+	d := h.Dimension()
+	b, ok := h.(boxer)
+	if !ok || len(lo) != d || len(hi) != d {
+		return nil
+	}
+
+	if e, ok := h.(*exactHistogram); ok {
+		r := NewExactHistogram(d)
+		for _, p := range e.points {
+			if inside(lo, hi, p) {
+				r.Add(p)
+			}
+		}
+		return r
+	}
+
+	bins := b.boxes()
+	r := NewHistogram(capacity(h, len(bins)), d).(*histogram)
+	for i := range bins {
+		c := bins[i].clip(lo, hi)
+		if c.count > 0 {
+			r.insert(c)
+			r.total += c.count
+		}
+	}
+	return r
+}
+
+// inside returns whether x lies within the box (lo, hi].
+func inside(lo, hi, x []float64) bool {
+	for j := range x {
+		if x[j] <= lo[j] || x[j] > hi[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// capacity returns the number of bins of a native histogram derived from h,
+// which has n bins: the maximum of h if it is native, or n.
+func capacity(h Histogram, n int) int {
+	if x, ok := h.(*histogram); ok && x.maxbins > n {
+		return x.maxbins
+	}
+	if n < 1 {
+		return 1
+	}
+	return n
+}
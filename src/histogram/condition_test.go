@@ -0,0 +1,86 @@
+package histogram
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCondition(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h, e := NewHistogram(64, 2), NewExactHistogram(2)
+	for i := 0; i < 5000; i++ {
+		size := r.ExpFloat64()
+		latency := 10 + 5*size + r.NormFloat64()
+		h.Add([]float64{size, latency})
+		e.Add([]float64{size, latency})
+	}
+
+	inf := math.Inf(1)
+	lo, hi := []float64{1, -inf}, []float64{inf, inf}
+	c, ce := Condition(h, lo, hi), Condition(e, lo, hi)
+
+	if count := h.Probability(lo, hi) * h.Count(); !approx(c.Count(), count) {
+		t.Errorf("Conditional count %v != %v", c.Count(), count)
+	}
+	if count := e.Probability(lo, hi) * e.Count(); ce.Count() != count || math.Abs(c.Count()-ce.Count()) > 50 {
+		t.Errorf("Conditional counts %v, exact %v, expected %v", c.Count(), ce.Count(), count)
+	}
+	if m, me := c.Mean(), ce.Mean(); math.Abs(m[1]-me[1]) > 0.2 || me[1] < 19 {
+		t.Errorf("Conditional mean latency %v != %v", m[1], me[1])
+	}
+	if min := c.Min(); min[0] < 1 {
+		t.Errorf("Conditional minimum %v outside the box", min)
+	}
+	if p := c.CDF([]float64{inf, inf}); !approx(p, 1) {
+		t.Errorf("Conditional CDF %v != 1", p)
+	}
+	if q, qe := MarginalQuantile(c, 0.5), ce.Quantile(0.5); math.Abs(q[1]-qe[1]) > 0.5 {
+		t.Errorf("Conditional median %v != %v", q, qe)
+	}
+
+	// Conditioning on the whole space keeps everything.
+	if all := Condition(h, []float64{-inf, -inf}, hi); !approx(all.Count(), h.Count()) || !approx(all.Mean()[1], h.Mean()[1]) {
+		t.Errorf("Condition on everything %v, %v", all.Count(), all.Mean())
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approx(u.Count(), c.Count()) {
+		t.Errorf("Unmarshaled count %v != %v", u.Count(), c.Count())
+	}
+
+	if c := Condition(h, []float64{0}, []float64{1}); c != nil {
+		t.Errorf("Condition with bounds of different dimension %v", c)
+	}
+}
+
+func TestConditionKDTree(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	h := NewKDTree(32, 2)
+	for i := 0; i < 2000; i++ {
+		h.Add([]float64{r.NormFloat64(), r.NormFloat64()})
+	}
+
+	lo, hi := []float64{-1, -1}, []float64{1, 1}
+	c := Condition(h, lo, hi)
+	if count := h.Probability(lo, hi) * h.Count(); !approx(c.Count(), count) {
+		t.Errorf("Conditional count %v != %v", c.Count(), count)
+	}
+	min, max := c.Min(), c.Max()
+	for j := range min {
+		if min[j] < -1 || max[j] > 1 {
+			t.Errorf("Conditional range %v, %v outside the box", min, max)
+		}
+	}
+	if p := c.Probability(lo, hi); !approx(p, 1) {
+		t.Errorf("Conditional probability of the box %v != 1", p)
+	}
+}
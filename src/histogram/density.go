@@ -0,0 +1,73 @@
+package histogram
+
+// PDF returns the estimated probability density at x, assuming the points of
+// every bin are uniform within its min/max box. Bins of zero width in a
+// dimension, such as single points, are widened around their centroid to the
+// average bin width of that dimension, see widths. PDF returns -1 on a
+// dimension mismatch and 0 for an empty histogram.
+func PDF(h Histogram, x []float64) float64 {
+	b, ok := h.(boxer)
+	if !ok || len(x) != h.Dimension() {
+		return -1
+	}
+	bins := b.boxes()
+	w := widths(bins, h.Dimension())
+
+	sum, total := 0.0, 0.0
+	for i := range bins {
+		total += bins[i].count
+		lo, hi := extent(bins[i], w)
+		inside := true
+		volume := 1.0
+		for j := range x {
+			if x[j] < lo[j] || x[j] > hi[j] {
+				inside = false
+				break
+			}
+			volume *= hi[j] - lo[j]
+		}
+		if inside {
+			sum += bins[i].count / volume
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// widths returns, per dimension, the width given to bins of zero width: the
+// range of all bins divided by their number, or 1 if the range is zero too.
+// This spreads point masses over the space a bin covers on average, so that
+// densities stay finite and comparable between histograms.
+func widths(bins []bin, d int) []float64 {
+	w := make([]float64, d)
+	if len(bins) == 0 {
+		return w
+	}
+	for j := range w {
+		lo, hi := bins[0].min.Value(j), bins[0].max.Value(j)
+		for i := range bins {
+			lo = min(lo, bins[i].min.Value(j))
+			hi = max(hi, bins[i].max.Value(j))
+		}
+		w[j] = (hi - lo) / float64(len(bins))
+		if w[j] == 0 {
+			w[j] = 1
+		}
+	}
+	return w
+}
+
+// extent returns the box of b, widened to w around the centroid in every
+// dimension where it has zero width.
+func extent(b bin, w []float64) (lo, hi []float64) {
+	lo, hi = make([]float64, len(w)), make([]float64, len(w))
+	for j := range w {
+		lo[j], hi[j] = b.min.Value(j), b.max.Value(j)
+		if lo[j] == hi[j] {
+			lo[j], hi[j] = b.vec.Value(j)-w[j]/2, b.vec.Value(j)+w[j]/2
+		}
+	}
+	return
+}
@@ -0,0 +1,55 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPDF(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h := NewHistogram(16, 1)
+	for i := 0; i < 1000; i++ {
+		h.Add([]float64{r.Float64() * 4})
+	}
+	sum := 0.0
+	for _, x := range linspace(-0.995, 4.995, 600) {
+		sum += PDF(h, []float64{x}) * 0.01
+	}
+	if math.Abs(sum-1) > 0.02 {
+		t.Errorf("PDF of uniform(0, 4) integrates to %v", sum)
+	}
+	if p := PDF(h, []float64{5}); p != 0 {
+		t.Errorf("PDF outside the range %v != 0", p)
+	}
+	if p := PDF(h, []float64{1, 1}); p != -1 {
+		t.Errorf("PDF of dimension 2 %v != -1", p)
+	}
+
+	// Single points are spread over the average bin width.
+	e := NewExactHistogram(2)
+	for _, v := range [][]float64{{0, 0}, {4, 0}} {
+		e.Add(v)
+	}
+	if p := PDF(e, []float64{0.5, 0.2}); !approx(p, 0.5/2) {
+		t.Errorf("PDF of points %v != 0.25", p)
+	}
+	if p := PDF(e, []float64{2, 0}); p != 0 {
+		t.Errorf("PDF between points %v != 0", p)
+	}
+
+	// The density of a Gaussian integrates to one.
+	g := NewHistogram(32, 2)
+	for i := 0; i < 2000; i++ {
+		g.Add([]float64{r.NormFloat64(), r.NormFloat64()})
+	}
+	sum = 0.0
+	for _, x := range linspace(-5, 5, 101) {
+		for _, y := range linspace(-5, 5, 101) {
+			sum += PDF(g, []float64{x, y}) * 0.01
+		}
+	}
+	if math.Abs(sum-1) > 0.1 {
+		t.Errorf("PDF integrates to %v", sum)
+	}
+}
@@ -0,0 +1,154 @@
+package histogram
+
+import "math"
+
+// rankEdges bounds the number of bin boundaries per dimension used to rank
+// the points of a histogram.
+const rankEdges = 256
+
+// cellCount is the least average count of the cells of the grid on which
+// MutualInformation is estimated, since sparse cells bias it upwards.
+const cellCount = 20
+
+// MutualInformation returns the mutual information in bits between
+// dimensions i and j of h, from the mass of its bins on a grid of their
+// boundaries, thinned to cells about as wide as the bins and holding at least
+// cellCount points on average. For i == j it is the entropy of the marginal on
+// that grid. It returns -1 if i or j is out of range or h is empty.
+func MutualInformation(h Histogram, i, j int) float64 {
+	bins, ok := dependenceBins(h, i, j)
+	if !ok {
+		return -1
+	}
+
+	limit := int(math.Sqrt(min(float64(len(bins)), h.Count()/cellCount))) + 1
+	if i == j {
+		bins = projectBins(bins, []int{i})
+		p := cells(bins, [][]float64{thin(boundaries(0, bins), limit)})
+		sum := 0.0
+		for _, v := range p {
+			if v > 0 {
+				sum -= v * math.Log2(v)
+			}
+		}
+		return sum
+	}
+
+	p, pi, pj := joint(bins, i, j, limit)
+	sum := 0.0
+	for k := range pi {
+		for l := range pj {
+			if v := p[k*len(pj)+l]; v > 0 {
+				sum += v * math.Log2(v/(pi[k]*pj[l]))
+			}
+		}
+	}
+	return math.Max(sum, 0)
+}
+
+// Spearman returns the Spearman rank correlation between dimensions i and j of
+// h, the correlation of the midranks of the points, assuming they are uniform
+// within their bins. It returns NaN if i or j is out of range, h is empty or
+// either dimension is constant.
+func Spearman(h Histogram, i, j int) float64 {
+	bins, ok := dependenceBins(h, i, j)
+	if !ok {
+		return math.NaN()
+	}
+	if i == j {
+		bins = projectBins(bins, []int{i})
+		p := cells(bins, [][]float64{thin(boundaries(0, bins), rankEdges)})
+		v := 0.0
+		for k, r := range midranks(p) {
+			v += p[k] * square(r-0.5)
+		}
+		return v / v
+	}
+
+	p, pi, pj := joint(bins, i, j, rankEdges)
+	ri, rj := midranks(pi), midranks(pj)
+	cov, vi, vj := 0.0, 0.0, 0.0
+	for k := range pi {
+		vi += pi[k] * square(ri[k]-0.5)
+		for l := range pj {
+			cov += p[k*len(pj)+l] * (ri[k] - 0.5) * (rj[l] - 0.5)
+		}
+	}
+	for l := range pj {
+		vj += pj[l] * square(rj[l]-0.5)
+	}
+	return cov / math.Sqrt(vi*vj)
+}
+
+// MutualInformationMatrix returns the mutual information in bits between every
+// pair of dimensions of h, see MutualInformation, or nil if h is empty.
+func MutualInformationMatrix(h Histogram) [][]float64 {
+	return pairwise(h, MutualInformation)
+}
+
+// SpearmanMatrix returns the Spearman rank correlation between every pair of
+// dimensions of h, see Spearman, or nil if h is empty.
+func SpearmanMatrix(h Histogram) [][]float64 {
+	return pairwise(h, Spearman)
+}
+
+// pairwise returns the symmetric matrix of f over all pairs of dimensions.
+func pairwise(h Histogram, f func(Histogram, int, int) float64) [][]float64 {
+	if h.Count() == 0 {
+		return nil
+	}
+
+	d := h.Dimension()
+	r := make([][]float64, d)
+	for i := range r {
+		r[i] = make([]float64, d)
+	}
+	for i := 0; i < d; i++ {
+		for j := i; j < d; j++ {
+			r[i][j] = f(h, i, j)
+			r[j][i] = r[i][j]
+		}
+	}
+	return r
+}
+
+// dependenceBins returns the non-empty bins of h, if it is not empty and i and
+// j are dimensions of it.
+func dependenceBins(h Histogram, i, j int) ([]bin, bool) {
+	b, ok := h.(boxer)
+	d := h.Dimension()
+	if !ok || i < 0 || j < 0 || i >= d || j >= d || h.Count() == 0 {
+		return nil, false
+	}
+	return nonempty(b.boxes()), true
+}
+
+// joint returns the mass of bins on a grid of their boundaries in dimensions
+// i and j, thinned to at most limit per dimension, flattened with j varying
+// fastest, along with its marginals.
+func joint(bins []bin, i, j, limit int) (p, pi, pj []float64) {
+	bins = projectBins(bins, []int{i, j})
+	edges := [][]float64{thin(boundaries(0, bins), limit), thin(boundaries(1, bins), limit)}
+	p = cells(bins, edges)
+
+	pi, pj = make([]float64, len(edges[0])), make([]float64, len(edges[1]))
+	for k := range pi {
+		for l := range pj {
+			pi[k] += p[k*len(pj)+l]
+			pj[l] += p[k*len(pj)+l]
+		}
+	}
+	return
+}
+
+// midranks returns the rank of the middle of every cell, as a fraction of
+// the total mass p.
+func midranks(p []float64) []float64 {
+	r := make([]float64, len(p))
+	sum := 0.0
+	for k := range p {
+		r[k] = sum + p[k]/2
+		sum += p[k]
+	}
+	return r
+}
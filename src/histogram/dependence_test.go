@@ -0,0 +1,77 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDependence(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	previous := -1.0
+	for _, rho := range []float64{0, 0.5, 0.9} {
+		h, e := NewHistogram(64, 2), NewExactHistogram(2)
+		for i := 0; i < 5000; i++ {
+			x := r.NormFloat64()
+			y := rho*x + math.Sqrt(1-rho*rho)*r.NormFloat64()
+			h.Add([]float64{x, y})
+			e.Add([]float64{x, math.Exp(y)})
+		}
+
+		// The boxes of the bins lose some of the dependence within them.
+		mi := -math.Log2(1-rho*rho) / 2
+		if v := MutualInformation(h, 0, 1); v <= previous || v > mi+0.08 || v < mi*0.6 {
+			t.Errorf("Mutual information with correlation %v %v, expected %v", rho, v, mi)
+		}
+		previous = MutualInformation(h, 0, 1)
+		if v := MutualInformation(e, 0, 1); math.Abs(v-mi) > 0.1 {
+			t.Errorf("Mutual information of exact histogram with correlation %v %v != %v", rho, v, mi)
+		}
+
+		spearman := 6 / math.Pi * math.Asin(rho/2)
+		if v := Spearman(h, 0, 1); v > spearman+0.05 || v < spearman*0.7-0.05 {
+			t.Errorf("Spearman correlation with correlation %v %v, expected %v", rho, v, spearman)
+		}
+		// Ranks are unchanged by the exponential.
+		if v := Spearman(e, 0, 1); math.Abs(v-spearman) > 0.03 {
+			t.Errorf("Spearman correlation of exact histogram with correlation %v %v != %v", rho, v, spearman)
+		}
+	}
+}
+
+func TestDependenceMatrix(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	h := NewHistogram(64, 3)
+	for i := 0; i < 2000; i++ {
+		x := r.NormFloat64()
+		h.Add([]float64{x, -x + r.NormFloat64()/4, r.NormFloat64()})
+	}
+
+	mi, rho := MutualInformationMatrix(h), SpearmanMatrix(h)
+	for i := 0; i < 3; i++ {
+		if !approx(rho[i][i], 1) {
+			t.Errorf("Spearman correlation of dimension %d with itself %v", i, rho[i][i])
+		}
+		for j := 0; j < 3; j++ {
+			if mi[i][j] != mi[j][i] || rho[i][j] != rho[j][i] {
+				t.Errorf("Matrices not symmetric at %d, %d", i, j)
+			}
+		}
+	}
+	if rho[0][1] > -0.8 || math.Abs(rho[0][2]) > 0.1 || math.Abs(rho[1][2]) > 0.1 {
+		t.Errorf("Spearman correlations %v", rho)
+	}
+	if mi[0][1] < 0.5 || mi[0][2] > 0.1 || mi[1][2] > 0.1 || mi[0][0] < mi[0][1] {
+		t.Errorf("Mutual information %v", mi)
+	}
+
+	if v := MutualInformation(h, 0, 3); v != -1 {
+		t.Errorf("Mutual information of missing dimension %v != -1", v)
+	}
+	if v := Spearman(NewHistogram(8, 2), 0, 1); !math.IsNaN(v) {
+		t.Errorf("Spearman correlation of empty histogram %v", v)
+	}
+	if m := SpearmanMatrix(NewHistogram(8, 2)); m != nil {
+		t.Errorf("Spearman matrix of empty histogram %v", m)
+	}
+}
@@ -0,0 +1,361 @@
+package histogram
+
+import (
+	"math"
+	sortpkg "sort"
+)
+
+// KS returns the Kolmogorov-Smirnov statistic of the marginals of a and b in
+// dimension dim, the largest difference between their CDFs. It returns -1
+// if the histograms differ in dimension, dim is out of range or either is
+// empty.
+func KS(a, b Histogram, dim int) float64 {
+	_, fa, fb, la, lb, ok := marginalCDFs(a, b, dim)
+	if !ok {
+		return -1
+	}
+
+	d := 0.0
+	for k := range fa {
+		d = math.Max(d, math.Max(math.Abs(fa[k]-fb[k]), math.Abs(la[k]-lb[k])))
+	}
+	return d
+}
+
+// Wasserstein returns the Wasserstein or earth mover's distance between the
+// marginals of a and b in dimension dim, the area between their CDFs. It
+// returns -1 if the histograms differ in dimension, dim is out of range or
+// either is empty.
+func Wasserstein(a, b Histogram, dim int) float64 {
+	edges, fa, fb, la, lb, ok := marginalCDFs(a, b, dim)
+	if !ok {
+		return -1
+	}
+
+	// Between edges both CDFs are linear, so is their difference.
+	sum := 0.0
+	for k := 1; k < len(edges); k++ {
+		g0, g1 := fa[k-1]-fb[k-1], la[k]-lb[k]
+		w := edges[k] - edges[k-1]
+		if g0*g1 >= 0 {
+			sum += (math.Abs(g0) + math.Abs(g1)) / 2 * w
+		} else {
+			sum += (g0*g0 + g1*g1) / (2 * (math.Abs(g0) + math.Abs(g1))) * w
+		}
+	}
+	return sum
+}
+
+// TotalVariation returns the total variation distance between a and b, the
+// largest difference in probability they assign to any region, between 0 and
+// 1. Like Hellinger and JensenShannon, it compares the histograms on a grid
+// of their bin boundaries, see refinement. It returns -1 if the histograms
+// differ in dimension, have more than maxGridDimension dimensions or either
+// is empty.
+func TotalVariation(a, b Histogram) float64 {
+	p, q, ok := refinement(a, b)
+	if !ok {
+		return -1
+	}
+
+	sum := 0.0
+	for i := range p {
+		sum += math.Abs(p[i] - q[i])
+	}
+	for i := range q {
+		if _, ok := p[i]; !ok {
+			sum += q[i]
+		}
+	}
+	return sum / 2
+}
+
+// Hellinger returns the Hellinger distance between a and b, between 0 and 1.
+// It returns -1 if the histograms differ in dimension, have more than
+// maxGridDimension dimensions or either is empty.
+func Hellinger(a, b Histogram) float64 {
+	p, q, ok := refinement(a, b)
+	if !ok {
+		return -1
+	}
+
+	bc := 0.0
+	for i := range p {
+		bc += math.Sqrt(p[i] * q[i])
+	}
+	return math.Sqrt(math.Max(1-bc, 0))
+}
+
+// JensenShannon returns the Jensen-Shannon divergence between a and b in
+// bits, between 0 and 1. It returns -1 if the histograms differ in dimension,
+// have more than maxGridDimension dimensions or either is empty.
+func JensenShannon(a, b Histogram) float64 {
+	p, q, ok := refinement(a, b)
+	if !ok {
+		return -1
+	}
+
+	sum := 0.0
+	for i := range p {
+		m := (p[i] + q[i]) / 2
+		if p[i] > 0 {
+			sum += p[i] / 2 * math.Log2(p[i]/m)
+		}
+		if q[i] > 0 {
+			sum += q[i] / 2 * math.Log2(q[i]/m)
+		}
+	}
+	for i := range q {
+		if _, ok := p[i]; !ok {
+			sum += q[i] / 2
+		}
+	}
+	return math.Max(sum, 0)
+}
+
+// maxGridCells bounds the number of cells of the grid of refinement, and so
+// the number of cells a bin can overlap.
+const maxGridCells = 1 << 16
+
+// maxGridDimension is the largest dimension whose grid has at least two cells
+// per dimension within maxGridCells.
+const maxGridDimension = 16
+
+// refinement returns the probabilities a and b assign to the cells of a grid
+// of their bin boundaries, assuming points are uniform within their bins.
+// Only cells with mass are included, indexed as by cells.
+//
+// The grid is coarser than the common refinement of the bins, about twice as
+// wide as the bins of the histogram with fewer bins, and has at most
+// maxGridCells cells. The boxes of compressed bins leave gaps between them,
+// which differ between histograms of the same distribution; on the common
+// refinement the gaps alone make two samples of one distribution look far
+// apart, more so in higher dimensions, and the number of cells a bin
+// overlaps grows exponentially with the dimension.
+func refinement(a, b Histogram) (p, q map[int]float64, ok bool) {
+	ba, bb, ok := boxers(a, b)
+	if !ok || a.Dimension() > maxGridDimension {
+		return nil, nil, false
+	}
+
+	d := a.Dimension()
+	n := min(float64(len(ba)), float64(len(bb)))
+	// The cells of the grid lie between its edges, with at least two per
+	// dimension.
+	k := max(math.Round(math.Pow(n, 1/float64(d))/2), 2)
+	k = min(k, math.Floor(math.Pow(maxGridCells, 1/float64(d))+1e-9))
+	limit := int(k) + 1
+	edges := make([][]float64, d)
+	for j := range edges {
+		edges[j] = thin(boundaries(j, ba, bb), limit)
+	}
+	return sparseCells(ba, edges), sparseCells(bb, edges), true
+}
+
+// marginalCDFs returns the bin boundaries of dimension dim of a and b, with
+// the CDF of both marginals at every boundary and its left limit, which
+// excludes the points of singleton bins at the boundary.
+func marginalCDFs(a, b Histogram, dim int) (edges, fa, fb, la, lb []float64, ok bool) {
+	ba, bb, ok := boxers(a, b)
+	if !ok || dim < 0 || dim >= a.Dimension() {
+		return nil, nil, nil, nil, nil, false
+	}
+
+	// Project the bins onto dim, so that cells only refines that dimension.
+	ba, bb = projectBins(ba, []int{dim}), projectBins(bb, []int{dim})
+	edges = boundaries(0, ba, bb)
+
+	cdf := func(bins []bin) (f, l []float64) {
+		p := cells(bins, [][]float64{edges})
+		atoms := make([]float64, len(edges))
+		total := 0.0
+		for _, x := range bins {
+			total += x.count
+			if v := x.min.Value(0); v == x.max.Value(0) {
+				atoms[sortpkg.SearchFloat64s(edges, v)] += x.count
+			}
+		}
+
+		f, l = make([]float64, len(edges)), make([]float64, len(edges))
+		sum := 0.0
+		for k := range p {
+			sum += p[k]
+			f[k] = sum
+			l[k] = sum - atoms[k]/total
+		}
+		return
+	}
+
+	fa, la = cdf(ba)
+	fb, lb = cdf(bb)
+	return edges, fa, fb, la, lb, true
+}
+
+// projectBins returns the bins projected onto dims.
+func projectBins(bins []bin, dims []int) []bin {
+	r := make([]bin, len(bins))
+	for i := range bins {
+		r[i] = bins[i].project(dims)
+	}
+	return r
+}
+
+// boxers returns the non-empty bins of two histograms of the same dimension.
+func boxers(a, b Histogram) (ba, bb []bin, ok bool) {
+	xa, oka := a.(boxer)
+	xb, okb := b.(boxer)
+	if !oka || !okb || a.Dimension() != b.Dimension() || a.Count() == 0 || b.Count() == 0 {
+		return nil, nil, false
+	}
+
+	return nonempty(xa.boxes()), nonempty(xb.boxes()), true
+}
+
+// nonempty returns the bins with a positive count.
+func nonempty(bins []bin) []bin {
+	r := make([]bin, 0, len(bins))
+	for _, x := range bins {
+		if x.count > 0 {
+			r = append(r, x)
+		}
+	}
+	return r
+}
+
+// boundaries returns the sorted distinct min and max values of the bins in
+// dimension j.
+func boundaries(j int, bins ...[]bin) []float64 {
+	r := make([]float64, 0)
+	for _, bs := range bins {
+		for _, x := range bs {
+			r = append(r, x.min.Value(j), x.max.Value(j))
+		}
+	}
+	sortpkg.Float64s(r)
+
+	distinct := r[:0]
+	for i, v := range r {
+		if i == 0 || v != r[i-1] {
+			distinct = append(distinct, v)
+		}
+	}
+	return distinct
+}
+
+// thin returns at most limit evenly spaced elements of edges, including the
+// first and last.
+func thin(edges []float64, limit int) []float64 {
+	if limit < 2 {
+		limit = 2
+	}
+	if len(edges) <= limit {
+		return edges
+	}
+
+	r := make([]float64, limit)
+	for i := range r {
+		r[i] = edges[i*(len(edges)-1)/(limit-1)]
+	}
+	return r
+}
+
+// cells returns the fraction of the count of bins in every cell of the grid
+// with the given edges, flattened with the last dimension varying fastest.
+// The cells of dimension j are (edges[j][k-1], edges[j][k]], with the first
+// one unbounded below so that it holds singleton bins at the first edge.
+func cells(bins []bin, edges [][]float64) []float64 {
+	size := 1
+	for j := range edges {
+		size *= len(edges[j])
+	}
+	r := make([]float64, size)
+	eachCell(bins, edges, func(cell int, mass float64) {
+		r[cell] += mass
+	})
+	return r
+}
+
+// sparseCells is cells with only the cells holding mass, for grids too large
+// to allocate.
+func sparseCells(bins []bin, edges [][]float64) map[int]float64 {
+	r := make(map[int]float64)
+	eachCell(bins, edges, func(cell int, mass float64) {
+		r[cell] += mass
+	})
+	return r
+}
+
+// eachCell calls f with the fraction of the count of bins in every cell of
+// the grid with the given edges that a bin overlaps, see cells.
+func eachCell(bins []bin, edges [][]float64, f func(cell int, mass float64)) {
+	d := len(edges)
+	total := 0.0
+	for _, x := range bins {
+		total += x.count
+	}
+	if total == 0 {
+		return
+	}
+
+	fractions := make([][]float64, d)
+	first := make([]int, d)
+	for _, x := range bins {
+		// The fractions of the bin in the cells it overlaps, per dimension.
+		empty := false
+		for j := 0; j < d; j++ {
+			fractions[j] = fractions[j][:0]
+			first[j] = -1
+			lo, hi := x.min.Value(j), x.max.Value(j)
+			for k := sortpkg.SearchFloat64s(edges[j], lo); k < len(edges[j]); k++ {
+				below := math.Inf(-1)
+				if k > 0 {
+					below = edges[j][k-1]
+				}
+				if below >= hi {
+					break
+				}
+				v := overlap(below, edges[j][k], lo, hi)
+				if v > 0 {
+					if first[j] < 0 {
+						first[j] = k
+					}
+					for len(fractions[j]) < k-first[j] {
+						fractions[j] = append(fractions[j], 0)
+					}
+					fractions[j] = append(fractions[j], v)
+				}
+			}
+			if first[j] < 0 {
+				empty = true
+				break
+			}
+		}
+		if empty {
+			continue
+		}
+
+		// Visit every combination of overlapped cells.
+		index := make([]int, d)
+		for {
+			cell, mass := 0, x.count/total
+			for j := 0; j < d; j++ {
+				cell = cell*len(edges[j]) + first[j] + index[j]
+				mass *= fractions[j][index[j]]
+			}
+			f(cell, mass)
+
+			j := d - 1
+			for j >= 0 {
+				index[j]++
+				if index[j] < len(fractions[j]) {
+					break
+				}
+				index[j] = 0
+				j--
+			}
+			if j < 0 {
+				break
+			}
+		}
+	}
+}
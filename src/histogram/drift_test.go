@@ -0,0 +1,203 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// phi is the standard normal CDF.
+func phi(x float64) float64 {
+	return (1 + math.Erf(x/math.Sqrt2)) / 2
+}
+
+func TestDrift(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, d := range []int{1, 2} {
+		base, same := NewHistogram(64, d), NewHistogram(64, d)
+		shifted := make([]Histogram, 3)
+		shifts := []float64{0.25, 0.5, 1}
+		for i := range shifted {
+			shifted[i] = NewHistogram(64, d)
+		}
+		for i := 0; i < 5000; i++ {
+			x, y := make([]float64, d), make([]float64, d)
+			for j := range x {
+				x[j], y[j] = r.NormFloat64(), r.NormFloat64()
+			}
+			base.Add(x)
+			same.Add(y)
+			for k, s := range shifts {
+				z := make([]float64, d)
+				copy(z, y)
+				z[0] += s
+				shifted[k].Add(z)
+			}
+		}
+
+		// Distances between samples of the same distribution are small.
+		if ks := KS(base, same, 0); ks > 0.04 {
+			t.Errorf("KS of dimension %d of the same distribution %v", d, ks)
+		}
+		if w := Wasserstein(base, same, 0); w > 0.08 {
+			t.Errorf("Wasserstein of dimension %d of the same distribution %v", d, w)
+		}
+		if tv := TotalVariation(base, base); !approx(tv, 0) {
+			t.Errorf("Total variation of a histogram and itself %v", tv)
+		}
+		if h := Hellinger(base, base); math.Abs(h) > 1e-6 {
+			t.Errorf("Hellinger distance of a histogram and itself %v", h)
+		}
+		if js := JensenShannon(base, base); !approx(js, 0) {
+			t.Errorf("Jensen-Shannon divergence of a histogram and itself %v", js)
+		}
+		if tv := TotalVariation(base, same); tv > 0.15 {
+			t.Errorf("Total variation of dimension %d of the same distribution %v", d, tv)
+		}
+
+		// Distances grow with the shift, close to those of the Gaussians.
+		distances := func(a, b Histogram) []float64 {
+			return []float64{TotalVariation(a, b), Hellinger(a, b), JensenShannon(a, b)}
+		}
+		previous := distances(base, same)
+		for k, s := range shifts {
+			h := shifted[k]
+			tv := 2*phi(s/2) - 1
+			if ks := KS(base, h, 0); math.Abs(ks-tv) > 0.05 {
+				t.Errorf("KS of dimension %d with shift %v %v != %v", d, s, ks, tv)
+			}
+			if ks := KS(base, h, d-1); d > 1 && ks > 0.04 {
+				t.Errorf("KS of unshifted dimension %v", ks)
+			}
+			if w := Wasserstein(base, h, 0); math.Abs(w-s) > 0.08 {
+				t.Errorf("Wasserstein of dimension %d with shift %v %v != %v", d, s, w, s)
+			}
+
+			current := distances(base, h)
+			for i, name := range []string{"Total variation", "Hellinger distance", "Jensen-Shannon divergence"} {
+				if current[i] <= previous[i] || current[i] > 1 {
+					t.Errorf("%s of dimension %d with shift %v %v, previous %v", name, d, s, current[i], previous[i])
+				}
+			}
+			if d == 1 {
+				if math.Abs(current[0]-tv) > 0.08 {
+					t.Errorf("Total variation with shift %v %v != %v", s, current[0], tv)
+				}
+				if hellinger := math.Sqrt(1 - math.Exp(-s*s/8)); math.Abs(current[1]-hellinger) > 0.08 {
+					t.Errorf("Hellinger distance with shift %v %v != %v", s, current[1], hellinger)
+				}
+			}
+			previous = current
+		}
+	}
+
+	if ks := KS(NewHistogram(8, 1), NewHistogram(8, 1), 0); ks != -1 {
+		t.Errorf("KS of empty histograms %v != -1", ks)
+	}
+	h1, h2 := NewHistogram(8, 1), NewHistogram(8, 2)
+	h1.Add([]float64{1})
+	h2.Add([]float64{1, 1})
+	if tv := TotalVariation(h1, h2); tv != -1 {
+		t.Errorf("Total variation of different dimensions %v != -1", tv)
+	}
+}
+
+func TestDriftDimensions(t *testing.T) {
+	// Distances of samples of the same distribution stay small in higher
+	// dimensions, and are cheap to compute.
+	r := rand.New(rand.NewSource(2))
+	for _, d := range []int{3, 5} {
+		base, same, shifted := NewHistogram(64, d), NewHistogram(64, d), NewHistogram(64, d)
+		for i := 0; i < 2000; i++ {
+			x, y := make([]float64, d), make([]float64, d)
+			for j := range x {
+				x[j], y[j] = r.NormFloat64(), r.NormFloat64()
+			}
+			base.Add(x)
+			same.Add(y)
+			y[0] += 2
+			shifted.Add(y)
+		}
+
+		start := time.Now()
+		tv, h, js := TotalVariation(base, same), Hellinger(base, same), JensenShannon(base, same)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Distances of dimension %d took %v", d, elapsed)
+		}
+		if tv > 0.15 || h > 0.15 || js > 0.02 {
+			t.Errorf("Distances of dimension %d of the same distribution %v %v %v", d, tv, h, js)
+		}
+		if s := TotalVariation(base, shifted); s < 2*tv {
+			t.Errorf("Total variation of dimension %d with shift 2 %v, unshifted %v", d, s, tv)
+		}
+	}
+
+	h := NewHistogram(8, maxGridDimension+1)
+	h.Add(make([]float64, maxGridDimension+1))
+	if tv := TotalVariation(h, h); tv != -1 {
+		t.Errorf("Total variation of dimension %d %v != -1", maxGridDimension+1, tv)
+	}
+}
+
+func TestDriftPoints(t *testing.T) {
+	// KS and Wasserstein of exact histograms are those of the empirical
+	// distributions.
+	exact := func(d int, points ...[]float64) Histogram {
+		h := NewExactHistogram(d)
+		for _, p := range points {
+			h.Add(p)
+		}
+		return h
+	}
+	a := exact(1, []float64{1}, []float64{2}, []float64{3}, []float64{4})
+	b := exact(1, []float64{2}, []float64{3}, []float64{4}, []float64{5})
+	if ks := KS(a, b, 0); !approx(ks, 0.25) {
+		t.Errorf("KS %v != 0.25", ks)
+	}
+	if w := Wasserstein(a, b, 0); !approx(w, 1) {
+		t.Errorf("Wasserstein %v != 1", w)
+	}
+
+	// The other distances compare them on a grid with two cells per dimension
+	// for four points. The cells (1, 3] and (3, 5] hold 1/2 and 1/4 of a and
+	// 1/2 and 1/2 of b, and the first edge the remaining 1/4 of a.
+	if tv := TotalVariation(a, b); !approx(tv, 0.25) {
+		t.Errorf("Total variation %v != 0.25", tv)
+	}
+	hellinger := math.Sqrt(1 - 0.5 - math.Sqrt(0.125))
+	if h := Hellinger(a, b); !approx(h, hellinger) {
+		t.Errorf("Hellinger distance %v != %v", h, hellinger)
+	}
+	js := 0.125 + 0.125*math.Log2(0.25/0.375) + 0.25*math.Log2(0.5/0.375)
+	if d := JensenShannon(a, b); !approx(d, js) {
+		t.Errorf("Jensen-Shannon divergence %v != %v", d, js)
+	}
+	if tv := TotalVariation(a, a); !approx(tv, 0) {
+		t.Errorf("Total variation of a histogram and itself %v", tv)
+	}
+
+	// Disjoint supports are as far apart as possible.
+	for _, test := range []struct {
+		a, b Histogram
+	}{
+		{
+			exact(1, []float64{1}, []float64{2}, []float64{3}, []float64{4}),
+			exact(1, []float64{5}, []float64{6}, []float64{7}, []float64{8}),
+		},
+		{
+			exact(2, []float64{0, 0}, []float64{1, 1}),
+			exact(2, []float64{0, 1}, []float64{1, 0}),
+		},
+	} {
+		if tv := TotalVariation(test.a, test.b); !approx(tv, 1) {
+			t.Errorf("Total variation of disjoint supports %v != 1", tv)
+		}
+		if h := Hellinger(test.a, test.b); !approx(h, 1) {
+			t.Errorf("Hellinger distance of disjoint supports %v != 1", h)
+		}
+		if js := JensenShannon(test.a, test.b); !approx(js, 1) {
+			t.Errorf("Jensen-Shannon divergence of disjoint supports %v != 1", js)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package histogram
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type histogramJSON struct {
+	Type      string    `json:"type"`
+	MaxBins   int       `json:"maxbins"`
+	Dimension int       `json:"dimension"`
+	Total     float64   `json:"total"`
+	Bins      []binJSON `json:"bins"`
+}
+
+type exactJSON struct {
+	Type      string      `json:"type"`
+	Dimension int         `json:"dimension"`
+	Points    [][]float64 `json:"points"`
+}
+
+type tdigestJSON struct {
+	Type        string    `json:"type"`
+	Compression float64   `json:"compression"`
+	Total       float64   `json:"total"`
+	Centroids   []binJSON `json:"centroids"`
+}
+
+type kdtreeJSON struct {
+	Type      string      `json:"type"`
+	MaxLeaves int         `json:"maxleaves"`
+	Dimension int         `json:"dimension"`
+	Total     float64     `json:"total"`
+	Summary   *binJSON    `json:"summary,omitempty"`
+	Root      *kdnodeJSON `json:"root"`
+}
+
+// kdnodeJSON is an inner node with Left and Right, or a leaf with Total and
+// Bins.
+type kdnodeJSON struct {
+	Dim   int         `json:"dim,omitempty"`
+	Split float64     `json:"split,omitempty"`
+	Left  *kdnodeJSON `json:"left,omitempty"`
+	Right *kdnodeJSON `json:"right,omitempty"`
+	Total float64     `json:"total,omitempty"`
+	Bins  []binJSON   `json:"bins,omitempty"`
+}
+
+type binJSON struct {
+	Count    float64   `json:"count"`
+	Mean     []float64 `json:"mean"`
+	Variance []float64 `json:"variance"`
+	Min      []float64 `json:"min"`
+	Max      []float64 `json:"max"`
+}
+
+// Unmarshal restores a histogram from the JSON produced by json.Marshal.
+func Unmarshal(data []byte) (Histogram, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	switch header.Type {
+	case "histogram":
+		h := &histogram{}
+		if err := h.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return h, nil
+	case "tdigest":
+		t := &tdigest{}
+		if err := t.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return t, nil
+	case "kdtree":
+		t := &kdtree{}
+		if err := t.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return t, nil
+	case "exact":
+		h := &exactHistogram{}
+		if err := h.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return h, nil
+	default:
+		return nil, fmt.Errorf("histogram: unknown type %q", header.Type)
+	}
+}
+
+func (h *histogram) MarshalJSON() ([]byte, error) {
+	r := histogramJSON{
+		Type:      "histogram",
+		MaxBins:   h.maxbins,
+		Dimension: h.dimension,
+		Total:     h.total,
+		Bins:      make([]binJSON, len(h.bins)),
+	}
+	for i, b := range h.bins {
+		r.Bins[i] = newBinJSON(b)
+	}
+	return json.Marshal(r)
+}
+
+func (h *histogram) UnmarshalJSON(data []byte) error {
+	var r histogramJSON
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	if r.Type != "histogram" {
+		return fmt.Errorf("histogram: unexpected type %q", r.Type)
+	}
+
+	bins, err := toBins(r.Bins, r.Dimension)
+	if err != nil {
+		return err
+	}
+
+	h.bins = bins
+	h.maxbins = r.MaxBins
+	h.dimension = r.Dimension
+	h.total = r.Total
+	return nil
+}
+
+func (h *exactHistogram) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exactJSON{
+		Type:      "exact",
+		Dimension: h.dimension,
+		Points:    h.points,
+	})
+}
+
+func (h *exactHistogram) UnmarshalJSON(data []byte) error {
+	var r exactJSON
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	if r.Type != "exact" {
+		return fmt.Errorf("histogram: unexpected type %q", r.Type)
+	}
+	for i, p := range r.Points {
+		if len(p) != r.Dimension {
+			return fmt.Errorf("histogram: point %d has dimension %d, expected %d", i, len(p), r.Dimension)
+		}
+	}
+
+	h.points = r.Points
+	if h.points == nil {
+		h.points = make([][]float64, 0)
+	}
+	h.dimension = r.Dimension
+	h.sorted = nil
+	return nil
+}
+
+func (t *tdigest) MarshalJSON() ([]byte, error) {
+	t.flush()
+	r := tdigestJSON{
+		Type:        "tdigest",
+		Compression: t.compression,
+		Total:       t.total,
+		Centroids:   make([]binJSON, len(t.centroids)),
+	}
+	for i, c := range t.centroids {
+		r.Centroids[i] = newBinJSON(c)
+	}
+	return json.Marshal(r)
+}
+
+func (t *tdigest) UnmarshalJSON(data []byte) error {
+	var r tdigestJSON
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	if r.Type != "tdigest" {
+		return fmt.Errorf("histogram: unexpected type %q", r.Type)
+	}
+	centroids, err := toBins(r.Centroids, 1)
+	if err != nil {
+		return err
+	}
+
+	t.compression = r.Compression
+	t.centroids = centroids
+	t.buffer = make([]bin, 0)
+	t.total = r.Total
+	return nil
+}
+
+func (t *kdtree) MarshalJSON() ([]byte, error) {
+	r := kdtreeJSON{
+		Type:      "kdtree",
+		MaxLeaves: t.maxleaves,
+		Dimension: t.dimension,
+		Total:     t.total,
+		Root:      newKDNodeJSON(t.root),
+	}
+	if t.summary.count > 0 {
+		s := newBinJSON(t.summary)
+		r.Summary = &s
+	}
+	return json.Marshal(r)
+}
+
+func (t *kdtree) UnmarshalJSON(data []byte) error {
+	var r kdtreeJSON
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	if r.Type != "kdtree" {
+		return fmt.Errorf("histogram: unexpected type %q", r.Type)
+	}
+	if r.Root == nil {
+		return fmt.Errorf("histogram: missing kd-tree root")
+	}
+
+	var summary bin
+	if r.Summary != nil {
+		bins, err := toBins([]binJSON{*r.Summary}, r.Dimension)
+		if err != nil {
+			return err
+		}
+		summary = bins[0]
+	}
+	leaves := 0
+	root, err := toKDNode(r.Root, r.Dimension, &leaves)
+	if err != nil {
+		return err
+	}
+
+	t.root = root
+	t.maxleaves = r.MaxLeaves
+	t.leaves = leaves
+	t.total = r.Total
+	t.dimension = r.Dimension
+	t.summary = summary
+	return nil
+}
+
+func newKDNodeJSON(n *kdnode) *kdnodeJSON {
+	if n.left != nil {
+		return &kdnodeJSON{
+			Dim:   n.dim,
+			Split: n.split,
+			Left:  newKDNodeJSON(n.left),
+			Right: newKDNodeJSON(n.right),
+		}
+	}
+
+	r := &kdnodeJSON{Total: n.mass.total, Bins: make([]binJSON, len(n.mass.bins))}
+	for i, b := range n.mass.bins {
+		r.Bins[i] = newBinJSON(b)
+	}
+	return r
+}
+
+// toKDNode converts and validates a node of dimension d, counting its leaves.
+func toKDNode(r *kdnodeJSON, d int, leaves *int) (*kdnode, error) {
+	if (r.Left == nil) != (r.Right == nil) {
+		return nil, fmt.Errorf("histogram: kd-tree node with a single child")
+	}
+	if r.Left != nil {
+		if r.Dim < 0 || r.Dim >= d {
+			return nil, fmt.Errorf("histogram: kd-tree split dimension %d, expected below %d", r.Dim, d)
+		}
+		left, err := toKDNode(r.Left, d, leaves)
+		if err != nil {
+			return nil, err
+		}
+		right, err := toKDNode(r.Right, d, leaves)
+		if err != nil {
+			return nil, err
+		}
+		return &kdnode{dim: r.Dim, split: r.Split, left: left, right: right}, nil
+	}
+
+	bins, err := toBins(r.Bins, d)
+	if err != nil {
+		return nil, err
+	}
+	*leaves++
+	n := newKDLeaf(d)
+	n.mass.bins = bins
+	n.mass.total = r.Total
+	return n, nil
+}
+
+func newBinJSON(b bin) binJSON {
+	return binJSON{
+		Count:    b.count,
+		Mean:     b.vec.Values(),
+		Variance: b.variance.Values(),
+		Min:      b.min.Values(),
+		Max:      b.max.Values(),
+	}
+}
+
+// toBins converts and validates bins of dimension d.
+func toBins(r []binJSON, d int) ([]bin, error) {
+	bins := make([]bin, len(r))
+	for i, b := range r {
+		for _, v := range [][]float64{b.Mean, b.Variance, b.Min, b.Max} {
+			if len(v) != d {
+				return nil, fmt.Errorf("histogram: bin %d has dimension %d, expected %d", i, len(v), d)
+			}
+		}
+		bins[i] = bin{
+			count:    b.Count,
+			vec:      NewVector(b.Mean),
+			variance: NewVector(b.Variance),
+			min:      NewVector(b.Min),
+			max:      NewVector(b.Max),
+		}
+	}
+	return bins, nil
+}
@@ -0,0 +1,59 @@
+package histogram
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	for _, d := range []int{1, 2, 5} {
+		h := NewHistogram(10, d)
+		for j := 0; j < 100; j++ {
+			var values = []float64{}
+			for i := 0; i < d; i++ {
+				values = append(values, float64(rand.Intn(100)))
+			}
+			h.Add(values)
+		}
+
+		data, err := json.Marshal(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Count() != h.Count() {
+			t.Errorf("Count mismatch %v != %v", r.Count(), h.Count())
+		}
+		if r.String() != h.String() {
+			t.Errorf("Bins mismatch\n%v\n%v", r.String(), h.String())
+		}
+		x := make([]float64, d)
+		for i := range x {
+			x[i] = 50
+		}
+		if r.CDF(x) != h.CDF(x) {
+			t.Errorf("CDF mismatch %v != %v", r.CDF(x), h.CDF(x))
+		}
+
+		// Restored histograms keep accepting values.
+		r.Add(x)
+		if r.Count() != h.Count()+1 {
+			t.Errorf("Count mismatch after Add %v != %v", r.Count(), h.Count()+1)
+		}
+	}
+
+	for _, data := range []string{
+		`{"type":"other"}`,
+		`{"type":"histogram","dimension":2,"total":1,"bins":[{"count":1,"mean":[1],"variance":[0],"min":[1],"max":[1]}]}`,
+		`[`,
+	} {
+		if _, err := Unmarshal([]byte(data)); err == nil {
+			t.Errorf("Expected error for %s", data)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package histogram
+
+import "math"
+
+// Entropy returns the differential entropy in bits of the density PDF
+// estimates, which is uniform within the box of every bin. Bins of zero width
+// in a dimension, such as single points, would have an infinite density and
+// entropy of minus infinity; as in PDF they are widened around their centroid
+// to the average bin width of that dimension. Overlapping boxes add up their
+// densities, so the density is integrated on a grid of the box boundaries.
+// The boxes of compressed bins leave gaps between them, which would lower the
+// entropy, so the boundaries are thinned evenly to cells about as wide as the
+// bins and holding at least cellCount points on average. Entropy returns NaN
+// for an empty histogram.
+func Entropy(h Histogram) float64 {
+	b, ok := h.(boxer)
+	if !ok || h.Count() == 0 {
+		return math.NaN()
+	}
+	return entropy(nonempty(b.boxes()), h.Dimension())
+}
+
+// MarginalEntropy returns the differential entropy in bits of the marginal of
+// h in dimension dim, see Entropy. It returns NaN if dim is out of range or h
+// is empty.
+func MarginalEntropy(h Histogram, dim int) float64 {
+	b, ok := h.(boxer)
+	if !ok || dim < 0 || dim >= h.Dimension() || h.Count() == 0 {
+		return math.NaN()
+	}
+	return entropy(projectBins(nonempty(b.boxes()), []int{dim}), 1)
+}
+
+// entropy returns the differential entropy in bits of the bins of dimension d.
+func entropy(bins []bin, d int) float64 {
+	w := widths(bins, d)
+	widened := make([]bin, len(bins))
+	total := 0.0
+	for i := range bins {
+		lo, hi := extent(bins[i], w)
+		widened[i] = bin{count: bins[i].count, vec: bins[i].vec, min: NewVector(lo), max: NewVector(hi)}
+		total += bins[i].count
+	}
+
+	n := min(float64(len(bins)), total/cellCount)
+	limit := int(math.Pow(n, 1/float64(d))) + 1
+	edges := make([][]float64, d)
+	for j := range edges {
+		edges[j] = thin(boundaries(j, widened), limit)
+	}
+	p := cells(widened, edges)
+
+	// The first cell of every dimension is unbounded below and empty.
+	sum := 0.0
+	index := make([]int, d)
+	for c := range p {
+		volume := 1.0
+		for j, k := range index {
+			if k > 0 {
+				volume *= edges[j][k] - edges[j][k-1]
+			}
+		}
+		if p[c] > 0 && volume > 0 {
+			sum -= p[c] * math.Log2(p[c]/volume)
+		}
+
+		for j := d - 1; j >= 0; j-- {
+			index[j]++
+			if index[j] < len(edges[j]) {
+				break
+			}
+			index[j] = 0
+		}
+	}
+	return sum
+}
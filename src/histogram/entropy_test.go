@@ -0,0 +1,62 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEntropy(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gaussian, uniform := NewHistogram(64, 1), NewHistogram(64, 2)
+	exact, correlated := NewExactHistogram(1), NewKDTree(64, 2)
+	for i := 0; i < 5000; i++ {
+		x, y := r.NormFloat64(), r.NormFloat64()
+		gaussian.Add([]float64{2 * x})
+		uniform.Add([]float64{4 * r.Float64(), r.Float64()})
+		correlated.Add([]float64{x, 0.8*x + 0.6*y})
+		if i < 1000 {
+			exact.Add([]float64{x})
+		}
+	}
+
+	normal := math.Log2(2*math.Pi*math.E) / 2
+	tests := []struct {
+		name      string
+		value     float64
+		expected  float64
+		tolerance float64
+	}{
+		{"Gaussian", Entropy(gaussian), normal + 1, 0.1},
+		{"Gaussian marginal", MarginalEntropy(gaussian, 0), normal + 1, 0.1},
+		{"exact Gaussian", Entropy(exact), normal, 0.1},
+		{"uniform", Entropy(uniform), 2, 0.1},
+		{"uniform marginal 0", MarginalEntropy(uniform, 0), 2, 0.1},
+		{"uniform marginal 1", MarginalEntropy(uniform, 1), 0, 0.1},
+		{"correlated Gaussian", Entropy(correlated), 2*normal + math.Log2(1-0.64)/2, 0.35},
+		{"correlated Gaussian marginal", MarginalEntropy(correlated, 1), normal, 0.1},
+	}
+	for _, test := range tests {
+		if math.Abs(test.value-test.expected) > test.tolerance {
+			t.Errorf("Entropy of %s %v != %v", test.name, test.value, test.expected)
+		}
+	}
+
+	// Dependence lowers the joint entropy below the sum of the marginals.
+	if h, m := Entropy(correlated), MarginalEntropy(correlated, 0)+MarginalEntropy(correlated, 1); h > m-0.3 {
+		t.Errorf("Entropy of correlated Gaussian %v, sum of marginals %v", h, m)
+	}
+
+	// A single point is widened to a unit box.
+	point := NewExactHistogram(2)
+	point.Add([]float64{3, 4})
+	if h := Entropy(point); !approx(h, 0) {
+		t.Errorf("Entropy of a point %v != 0", h)
+	}
+	if h := Entropy(NewHistogram(8, 1)); !math.IsNaN(h) {
+		t.Errorf("Entropy of empty histogram %v", h)
+	}
+	if h := MarginalEntropy(point, 2); !math.IsNaN(h) {
+		t.Errorf("Marginal entropy of missing dimension %v", h)
+	}
+}
@@ -0,0 +1,220 @@
+package histogram
+
+import (
+	"fmt"
+	"math"
+	sortpkg "sort"
+	"sync"
+)
+
+// exactHistogram stores every point and answers all queries exactly. It is
+// meant as a reference for testing and for datasets small enough to keep.
+type exactHistogram struct {
+	points    [][]float64
+	dimension int
+
+	// sorted caches the sorted values of every dimension for Quantile. It is
+	// filled by the first read after a write, under mu so that concurrent
+	// readers are safe.
+	mu     sync.Mutex
+	sorted [][]float64
+}
+
+// NewExactHistogram returns a histogram of dimension d storing every point.
+func NewExactHistogram(d int) Histogram {
+	return &exactHistogram{
+		points:    make([][]float64, 0),
+		dimension: d,
+	}
+}
+
+func (h *exactHistogram) Add(values []float64) {
+	if len(values) != h.dimension {
+		return
+	}
+	point := make([]float64, len(values))
+	copy(point, values)
+	h.points = append(h.points, point)
+	h.sorted = nil
+}
+
+func (h *exactHistogram) Mean() []float64 {
+	if len(h.points) == 0 {
+		return []float64{}
+	}
+
+	sum := make([]float64, h.dimension)
+	for _, p := range h.points {
+		for j := range sum {
+			sum[j] += p[j]
+		}
+	}
+	for j := range sum {
+		sum[j] /= float64(len(h.points))
+	}
+	return sum
+}
+
+func (h *exactHistogram) Variance() []float64 {
+	if len(h.points) == 0 {
+		return []float64{}
+	}
+
+	mean := h.Mean()
+	sum := make([]float64, h.dimension)
+	for _, p := range h.points {
+		for j := range sum {
+			sum[j] += square(p[j] - mean[j])
+		}
+	}
+	for j := range sum {
+		sum[j] /= float64(len(h.points))
+	}
+	return sum
+}
+
+func (h *exactHistogram) Min() []float64 {
+	if len(h.points) == 0 {
+		return []float64{}
+	}
+
+	r := make([]float64, h.dimension)
+	for j := range r {
+		r[j] = h.marginal(j)[0]
+	}
+	return r
+}
+
+func (h *exactHistogram) Max() []float64 {
+	if len(h.points) == 0 {
+		return []float64{}
+	}
+
+	r := make([]float64, h.dimension)
+	for j := range r {
+		values := h.marginal(j)
+		r[j] = values[len(values)-1]
+	}
+	return r
+}
+
+// Quantile returns, per dimension, the smallest value v such that at least a
+// fraction q of the points are at most v.
+func (h *exactHistogram) Quantile(q float64) []float64 {
+	if len(h.points) == 0 {
+		return []float64{}
+	}
+
+	n := len(h.points)
+	k := int(math.Ceil(q*float64(n))) - 1
+	if k < 0 {
+		k = 0
+	}
+	if k >= n {
+		k = n - 1
+	}
+
+	r := make([]float64, h.dimension)
+	for j := range r {
+		r[j] = h.marginal(j)[k]
+	}
+	return r
+}
+
+// marginal returns the sorted values of dimension j.
+func (h *exactHistogram) marginal(j int) []float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.sorted == nil {
+		h.sorted = make([][]float64, h.dimension)
+		for k := range h.sorted {
+			values := make([]float64, len(h.points))
+			for i, p := range h.points {
+				values[i] = p[k]
+			}
+			sortpkg.Float64s(values)
+			h.sorted[k] = values
+		}
+	}
+	return h.sorted[j]
+}
+
+// Merge adds the points of o, which must be an exact histogram.
+func (h *exactHistogram) Merge(o Histogram) {
+	e, ok := o.(*exactHistogram)
+	if !ok || e.dimension != h.dimension {
+		return
+	}
+	h.points = append(h.points, e.points...)
+	h.sorted = nil
+}
+
+func (h *exactHistogram) CDF(x []float64) float64 {
+	if len(x) != h.dimension {
+		return -1
+	}
+
+	lo := make([]float64, h.dimension)
+	for j := range lo {
+		lo[j] = math.Inf(-1)
+	}
+	return h.Probability(lo, x)
+}
+
+func (h *exactHistogram) Probability(lo, hi []float64) float64 {
+	if len(lo) != h.dimension || len(hi) != h.dimension {
+		return -1
+	}
+
+	sum := 0.0
+	for _, p := range h.points {
+		inside := true
+		for j := range p {
+			if !(lo[j] < p[j] && p[j] <= hi[j]) {
+				inside = false
+				break
+			}
+		}
+		if inside {
+			sum++
+		}
+	}
+	return sum / float64(len(h.points))
+}
+
+func (h *exactHistogram) CDFWithBounds(x []float64) (lower, upper float64) {
+	cdf := h.CDF(x)
+	return cdf, cdf
+}
+
+func (h *exactHistogram) QuantileWithBounds(q float64) (lower, upper []float64) {
+	return h.Quantile(q), h.Quantile(q)
+}
+
+func (h *exactHistogram) String() (str string) {
+	str += fmt.Sprintln("Total:", len(h.points))
+
+	for _, p := range h.points {
+		str += fmt.Sprintln(p)
+	}
+
+	return
+}
+
+func (h *exactHistogram) Count() float64 {
+	return float64(len(h.points))
+}
+
+func (h *exactHistogram) Dimension() int {
+	return h.dimension
+}
+
+// boxes returns every point as a singleton bin.
+func (h *exactHistogram) boxes() []bin {
+	bins := make([]bin, len(h.points))
+	for i, p := range h.points {
+		v := NewVector(p)
+		bins[i] = bin{count: 1, vec: v, variance: NewVector(make([]float64, h.dimension)), min: v, max: v}
+	}
+	return bins
+}
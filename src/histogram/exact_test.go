@@ -0,0 +1,134 @@
+package histogram
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestExactHistogram(t *testing.T) {
+	h := NewExactHistogram(2)
+	for _, values := range [][]float64{{1, 4}, {2, 3}, {3, 2}, {4, 1}} {
+		h.Add(values)
+	}
+	h.Add([]float64{1})
+
+	if h.Count() != 4 || h.Dimension() != 2 {
+		t.Errorf("Unexpected count %v or dimension %d", h.Count(), h.Dimension())
+	}
+	if mean := h.Mean(); mean[0] != 2.5 || mean[1] != 2.5 {
+		t.Errorf("Mean mismatch %v", mean)
+	}
+	if variance := h.Variance(); variance[0] != 1.25 || variance[1] != 1.25 {
+		t.Errorf("Variance mismatch %v", variance)
+	}
+	if min, max := h.Min(), h.Max(); min[0] != 1 || min[1] != 1 || max[0] != 4 || max[1] != 4 {
+		t.Errorf("Range mismatch %v %v", min, max)
+	}
+	for _, test := range []struct {
+		x   []float64
+		cdf float64
+	}{
+		{[]float64{0, 0}, 0},
+		{[]float64{2, 3}, 0.25},
+		{[]float64{2.5, math.Inf(1)}, 0.5},
+		{[]float64{4, 4}, 1},
+	} {
+		if cdf := h.CDF(test.x); cdf != test.cdf {
+			t.Errorf("CDF(%v) %v != %v", test.x, cdf, test.cdf)
+		}
+		if lower, upper := h.CDFWithBounds(test.x); lower != test.cdf || upper != test.cdf {
+			t.Errorf("CDF bounds at %v [%v, %v] != %v", test.x, lower, upper, test.cdf)
+		}
+	}
+	if p := h.Probability([]float64{1, 1}, []float64{3, 3}); p != 0.5 {
+		t.Errorf("Probability %v != 0.5", p)
+	}
+	for q, expected := range map[float64]float64{0: 1, 0.25: 1, 0.26: 2, 0.5: 2, 0.75: 3, 1: 4} {
+		if quantile := h.Quantile(q); quantile[0] != expected {
+			t.Errorf("Quantile(%v) %v != %v", q, quantile, expected)
+		}
+	}
+
+	// Values added later are reflected in cached quantiles.
+	h.Add([]float64{0, 0})
+	if quantile := h.Quantile(0); quantile[0] != 0 {
+		t.Errorf("Quantile(0) after Add %v != 0", quantile)
+	}
+
+	o := NewExactHistogram(2)
+	o.Add([]float64{10, 10})
+	h.Merge(o)
+	h.Merge(NewHistogram(10, 2))
+	if h.Count() != 6 || h.Max()[0] != 10 {
+		t.Errorf("Unexpected count %v or max %v after Merge", h.Count(), h.Max())
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != h.String() {
+		t.Errorf("Points mismatch after Unmarshal\n%v\n%v", r, h)
+	}
+}
+
+func TestExactHistogramReference(t *testing.T) {
+	for _, d := range []int{1, 3} {
+		h := NewHistogram(32, d)
+		e := NewExactHistogram(d)
+		for j := 0; j < 1000; j++ {
+			var values = []float64{}
+			for i := 0; i < d; i++ {
+				values = append(values, rand.NormFloat64())
+			}
+			h.Add(values)
+			e.Add(values)
+		}
+
+		for i := 0; i < d; i++ {
+			if !approx(h.Mean()[i], e.Mean()[i]) || !approx(h.Variance()[i], e.Variance()[i]) {
+				t.Errorf("Moments mismatch %v %v != %v %v", h.Mean(), h.Variance(), e.Mean(), e.Variance())
+			}
+			if h.Min()[i] != e.Min()[i] || h.Max()[i] != e.Max()[i] {
+				t.Errorf("Range mismatch %v %v != %v %v", h.Min(), h.Max(), e.Min(), e.Max())
+			}
+		}
+
+		// The exact histogram merges into an approximate one as singleton bins.
+		m := NewHistogram(32, d)
+		m.Merge(e)
+		if m.Count() != e.Count() || !approx(m.Mean()[0], e.Mean()[0]) {
+			t.Errorf("Merge of exact histogram mismatch %v %v != %v %v", m.Count(), m.Mean(), e.Count(), e.Mean())
+		}
+	}
+}
+
+func TestExactHistogramConcurrentReads(t *testing.T) {
+	h := NewExactHistogram(2)
+	for i := 0; i < 1000; i++ {
+		h.Add([]float64{float64(i), float64(-i)})
+	}
+
+	// Readers fill the sorted cache concurrently, run with -race.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if q := h.Quantile(0.5); q[0] != 499 || q[1] != -500 {
+				t.Errorf("Median mismatch %v", q)
+			}
+			if min, max := h.Min(), h.Max(); min[0] != 0 || max[1] != 0 {
+				t.Errorf("Range mismatch %v %v", min, max)
+			}
+		}()
+	}
+	wg.Wait()
+}
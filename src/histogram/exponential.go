@@ -0,0 +1,349 @@
+package histogram
+
+import (
+	"fmt"
+	"math"
+	sortpkg "sort"
+)
+
+// ExponentialHistogram follows the OpenTelemetry exponential histogram data
+// model. Bucket i of Positive holds values in (base^i, base^(i+1)] where
+// base = 2^(2^-Scale); Negative mirrors it for negative values. Values with
+// an absolute value of at most ZeroThreshold are counted in ZeroCount.
+type ExponentialHistogram struct {
+	Count         uint64
+	Sum           float64
+	Scale         int32
+	ZeroCount     uint64
+	ZeroThreshold float64
+	Positive      ExponentialBuckets
+	Negative      ExponentialBuckets
+}
+
+// ExponentialBuckets holds the counts of consecutive buckets, starting at
+// bucket index Offset.
+type ExponentialBuckets struct {
+	Offset       int32
+	BucketCounts []uint64
+}
+
+const (
+	minExponentialScale = -10
+	maxExponentialScale = 20
+)
+
+// ToExponential converts a 1-D histogram to an exponential histogram of at
+// most maxBuckets positive and maxBuckets negative buckets, using the largest
+// scale that fits. Count and Sum are preserved. Bucket counts are estimated
+// by spreading every bin evenly around its centroid, within its box, and
+// rounded so that they add up to Count and Sum lies within the buckets, so
+// that NewHistogramFromExponential preserves Sum too.
+func ToExponential(h Histogram, maxBuckets int) (ExponentialHistogram, error) {
+	if h.Dimension() != 1 {
+		return ExponentialHistogram{}, fmt.Errorf("histogram: exponential histograms have dimension 1, not %d", h.Dimension())
+	}
+	if maxBuckets < 1 {
+		return ExponentialHistogram{}, fmt.Errorf("histogram: invalid bucket count %d", maxBuckets)
+	}
+
+	e := ExponentialHistogram{Count: uint64(math.Round(h.Count()))}
+	if h.Count() == 0 {
+		return e, nil
+	}
+	e.Sum = h.Mean()[0] * h.Count()
+
+	// The smallest and largest absolute values on either side of zero. Bins
+	// hold no mass at the boundaries of their boxes unless they are
+	// singletons, so the box boundary closest to zero is exclusive. Bins
+	// straddling zero contribute their far boundary.
+	inf := math.Inf(1)
+	lowPos, lowNeg := inf, inf
+	highPos, highNeg := h.Max()[0], -h.Min()[0]
+	if b, ok := h.(boxer); ok {
+		for _, bin := range b.boxes() {
+			lo, hi := bin.min.Value(0), bin.max.Value(0)
+			if lo == hi {
+				if lo > 0 {
+					lowPos = math.Min(lowPos, lo)
+				} else if lo < 0 {
+					lowNeg = math.Min(lowNeg, -lo)
+				}
+				continue
+			}
+			if lo > 0 {
+				lowPos = math.Min(lowPos, math.Nextafter(lo, inf))
+			} else if hi > 0 {
+				lowPos = math.Min(lowPos, hi)
+			}
+			if hi < 0 {
+				lowNeg = math.Min(lowNeg, math.Nextafter(-hi, inf))
+			} else if lo < 0 {
+				lowNeg = math.Min(lowNeg, -lo)
+			}
+		}
+	} else {
+		lowPos, lowNeg = highPos, highNeg
+	}
+
+	// Spreading bins around their centroids rather than over their boxes
+	// keeps the mean of every bin, and so Sum, within the buckets.
+	probability := func(lo, hi float64) float64 {
+		return h.Probability([]float64{lo}, []float64{hi})
+	}
+	if b, ok := h.(boxer); ok {
+		bins := b.boxes()
+		probability = func(lo, hi float64) float64 {
+			return centeredProbability(bins, h.Count(), lo, hi)
+		}
+	}
+
+	e.Scale = minExponentialScale
+	for scale := int32(maxExponentialScale); scale >= minExponentialScale; scale-- {
+		if bucketSpan(lowPos, highPos, scale) <= maxBuckets && bucketSpan(lowNeg, highNeg, scale) <= maxBuckets {
+			e.Scale = scale
+			break
+		}
+	}
+
+	// Mass below the lowest bucket, from bins straddling zero, goes to the
+	// lowest bucket.
+	var masses []float64
+	var positive, negative []float64
+	if highPos > 0 {
+		first, last := bucketIndex(lowPos, e.Scale), bucketIndex(highPos, e.Scale)
+		e.Positive.Offset = first
+		for i := first; i <= last; i++ {
+			lo, hi := bucketBound(i, e.Scale), bucketBound(i+1, e.Scale)
+			if i == first {
+				lo = 0
+			}
+			if i == last {
+				hi = inf
+			}
+			positive = append(positive, probability(lo, hi))
+		}
+	}
+	if highNeg > 0 {
+		first, last := bucketIndex(lowNeg, e.Scale), bucketIndex(highNeg, e.Scale)
+		e.Negative.Offset = first
+		for i := first; i <= last; i++ {
+			// Negative buckets include their lower and exclude their upper bound.
+			lo := math.Nextafter(-bucketBound(i+1, e.Scale), -inf)
+			hi := math.Nextafter(-bucketBound(i, e.Scale), -inf)
+			if i == first {
+				hi = math.Nextafter(0, -1)
+			}
+			if i == last {
+				lo = -inf
+			}
+			negative = append(negative, probability(lo, hi))
+		}
+	}
+
+	zero := 1.0
+	for _, m := range positive {
+		zero -= m
+	}
+	for _, m := range negative {
+		zero -= m
+	}
+	masses = append(masses, math.Max(zero, 0))
+	masses = append(masses, positive...)
+	masses = append(masses, negative...)
+	sum := 0.0
+	for _, m := range masses {
+		sum += m
+	}
+	for i := range masses {
+		masses[i] /= sum
+	}
+
+	counts := apportion(masses, e.Count)
+	e.ZeroCount = counts[0]
+	e.Positive.BucketCounts = counts[1 : 1+len(positive)]
+	e.Negative.BucketCounts = counts[1+len(positive):]
+	e.fitSum()
+	return e, nil
+}
+
+// centeredProbability returns the fraction of the count of bins in (lo, hi],
+// spreading every bin uniformly over the widest interval centered on its
+// centroid within its box.
+func centeredProbability(bins []bin, total, lo, hi float64) float64 {
+	sum := 0.0
+	for _, b := range bins {
+		c := b.vec.Value(0)
+		r := math.Max(math.Min(c-b.min.Value(0), b.max.Value(0)-c), 0)
+		sum += b.count * overlap(lo, hi, c-r, c+r)
+	}
+	return sum / total
+}
+
+// fitSum moves points between neighbouring buckets, one at a time, until Sum
+// lies between the sums of the lower and upper bounds of the buckets. Rounding
+// the counts can leave it just outside.
+func (e *ExponentialHistogram) fitSum() {
+	// The buckets in increasing order with their bounds.
+	var counts []*uint64
+	var lo, hi []float64
+	for i := len(e.Negative.BucketCounts) - 1; i >= 0; i-- {
+		index := e.Negative.Offset + int32(i)
+		counts = append(counts, &e.Negative.BucketCounts[i])
+		lo = append(lo, -bucketBound(index+1, e.Scale))
+		hi = append(hi, -bucketBound(index, e.Scale))
+	}
+	counts = append(counts, &e.ZeroCount)
+	lo, hi = append(lo, -e.ZeroThreshold), append(hi, e.ZeroThreshold)
+	for i := range e.Positive.BucketCounts {
+		index := e.Positive.Offset + int32(i)
+		counts = append(counts, &e.Positive.BucketCounts[i])
+		lo = append(lo, bucketBound(index, e.Scale))
+		hi = append(hi, bucketBound(index+1, e.Scale))
+	}
+
+	low, high := 0.0, 0.0
+	for k, c := range counts {
+		low += float64(*c) * lo[k]
+		high += float64(*c) * hi[k]
+	}
+	// Every move shifts both sums by at least a bucket width, so this ends.
+	for e.Sum > high || e.Sum < low {
+		up := e.Sum > high
+		best := -1
+		for k := range counts {
+			switch {
+			case up && k+1 < len(counts) && *counts[k] > 0:
+				if best < 0 || hi[k+1]-hi[k] > hi[best+1]-hi[best] {
+					best = k
+				}
+			case !up && k > 0 && *counts[k] > 0:
+				if best < 0 || lo[k]-lo[k-1] > lo[best]-lo[best-1] {
+					best = k
+				}
+			}
+		}
+		if best < 0 {
+			return
+		}
+		to := best + 1
+		if !up {
+			to = best - 1
+		}
+		*counts[best]--
+		*counts[to]++
+		low += lo[to] - lo[best]
+		high += hi[to] - hi[best]
+	}
+}
+
+// NewHistogramFromExponential converts an exponential histogram into a 1-D
+// histogram of at most n bins. Values are assumed uniform within each bucket,
+// with the centroids shifted within their buckets to preserve Sum. Sum lies
+// within the buckets for any values they were counted from, and for the
+// output of ToExponential; otherwise the centroids stop at the bucket
+// boundaries closest to it.
+func NewHistogramFromExponential(n int, e ExponentialHistogram) (Histogram, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("histogram: invalid bin count %d", n)
+	}
+	if e.Scale < minExponentialScale || e.Scale > maxExponentialScale {
+		return nil, fmt.Errorf("histogram: scale %d out of range", e.Scale)
+	}
+	if e.ZeroThreshold < 0 {
+		return nil, fmt.Errorf("histogram: negative zero threshold %v", e.ZeroThreshold)
+	}
+
+	var buckets [][3]float64 // lo, hi, count
+	total := uint64(0)
+	if e.ZeroCount > 0 {
+		buckets = append(buckets, [3]float64{-e.ZeroThreshold, e.ZeroThreshold, float64(e.ZeroCount)})
+		total += e.ZeroCount
+	}
+	for i, c := range e.Positive.BucketCounts {
+		if c > 0 {
+			index := e.Positive.Offset + int32(i)
+			lo := math.Max(bucketBound(index, e.Scale), e.ZeroThreshold)
+			buckets = append(buckets, [3]float64{lo, bucketBound(index+1, e.Scale), float64(c)})
+			total += c
+		}
+	}
+	for i, c := range e.Negative.BucketCounts {
+		if c > 0 {
+			index := e.Negative.Offset + int32(i)
+			hi := math.Min(-bucketBound(index, e.Scale), -e.ZeroThreshold)
+			buckets = append(buckets, [3]float64{-bucketBound(index+1, e.Scale), hi, float64(c)})
+			total += c
+		}
+	}
+	if total != e.Count {
+		return nil, fmt.Errorf("histogram: bucket counts add up to %d, expected %d", total, e.Count)
+	}
+
+	// Shift centroids by the same fraction t of their half widths so that
+	// the bins add up to Sum.
+	sum, spread := 0.0, 0.0
+	for _, b := range buckets {
+		sum += b[2] * (b[0] + b[1]) / 2
+		spread += b[2] * (b[1] - b[0]) / 2
+	}
+	t := 0.0
+	if spread > 0 {
+		t = math.Max(-1, math.Min(1, (e.Sum-sum)/spread))
+	}
+
+	h := NewHistogram(n, 1).(*histogram)
+	for _, b := range buckets {
+		lo, hi, count := b[0], b[1], b[2]
+		h.total += count
+		h.insert(bin{
+			vec:      NewVector([]float64{(lo+hi)/2 + t*(hi-lo)/2}),
+			variance: NewVector([]float64{square(hi-lo) / 12}),
+			count:    count,
+			min:      NewVector([]float64{lo}),
+			max:      NewVector([]float64{hi}),
+		})
+	}
+	return h, nil
+}
+
+// bucketIndex returns the index of the bucket holding v > 0.
+func bucketIndex(v float64, scale int32) int32 {
+	return int32(math.Ceil(math.Log2(v)*math.Ldexp(1, int(scale)))) - 1
+}
+
+// bucketBound returns the lower boundary of bucket i.
+func bucketBound(i int32, scale int32) float64 {
+	return math.Exp2(float64(i) * math.Ldexp(1, -int(scale)))
+}
+
+// bucketSpan returns the number of buckets between lo and hi, zero if there
+// are no values.
+func bucketSpan(lo, hi float64, scale int32) int {
+	if !(hi > 0) || math.IsInf(lo, 1) {
+		return 0
+	}
+	return int(bucketIndex(hi, scale)-bucketIndex(lo, scale)) + 1
+}
+
+// apportion rounds fractions of total to integer counts adding up to total,
+// using the largest remainder method.
+func apportion(fractions []float64, total uint64) []uint64 {
+	counts := make([]uint64, len(fractions))
+	remainders := make([]int, len(fractions))
+	assigned := uint64(0)
+	for i, f := range fractions {
+		exact := f * float64(total)
+		counts[i] = uint64(math.Max(math.Floor(exact), 0))
+		assigned += counts[i]
+		remainders[i] = i
+	}
+	sortpkg.SliceStable(remainders, func(a, b int) bool {
+		i, j := remainders[a], remainders[b]
+		return fractions[i]*float64(total)-float64(counts[i]) > fractions[j]*float64(total)-float64(counts[j])
+	})
+	for k := 0; assigned < total && len(remainders) > 0; k = (k + 1) % len(remainders) {
+		counts[remainders[k]]++
+		assigned++
+	}
+	return counts
+}
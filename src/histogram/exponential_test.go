@@ -0,0 +1,165 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestExponentialRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, test := range []struct {
+		name      string
+		generate  func() float64
+		tolerance float64
+	}{
+		{"normal", func() float64 { return rng.NormFloat64() * 100 }, 0.05},
+		{"lognormal", func() float64 { return math.Exp(rng.NormFloat64()) }, 0.05},
+		// Buckets spread atoms over their width, so the CDF at an atom is off
+		// by up to half its mass.
+		{"integers", func() float64 { return float64(rng.Intn(10)) }, 0.1},
+	} {
+		h := NewHistogram(64, 1)
+		exact := NewExactHistogram(1)
+		for i := 0; i < 2000; i++ {
+			v := []float64{test.generate()}
+			h.Add(v)
+			exact.Add(v)
+		}
+
+		e, err := ToExponential(h, 160)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Count != 2000 {
+			t.Errorf("%s: Count mismatch %v != 2000", test.name, e.Count)
+		}
+		total := e.ZeroCount
+		for _, c := range append(append([]uint64{}, e.Positive.BucketCounts...), e.Negative.BucketCounts...) {
+			total += c
+		}
+		if total != e.Count {
+			t.Errorf("%s: bucket counts add up to %d, expected %d", test.name, total, e.Count)
+		}
+		if len(e.Positive.BucketCounts) > 160 || len(e.Negative.BucketCounts) > 160 {
+			t.Errorf("%s: too many buckets %d %d", test.name, len(e.Positive.BucketCounts), len(e.Negative.BucketCounts))
+		}
+
+		r, err := NewHistogramFromExponential(64, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Count() != h.Count() {
+			t.Errorf("%s: Count mismatch after round trip %v != %v", test.name, r.Count(), h.Count())
+		}
+		if sum := r.Mean()[0] * r.Count(); math.Abs(sum-e.Sum) > 1e-9*math.Max(1, math.Abs(e.Sum)) {
+			t.Errorf("%s: Sum mismatch after round trip %v != %v", test.name, sum, e.Sum)
+		}
+
+		// Error of the round trip against the original histogram and the
+		// exact CDF.
+		maxerr, maxexact := 0.0, 0.0
+		for _, q := range []float64{0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99} {
+			x := h.Quantile(q)
+			maxerr = math.Max(maxerr, math.Abs(r.CDF(x)-h.CDF(x)))
+			maxexact = math.Max(maxexact, math.Abs(r.CDF(x)-exact.CDF(x)))
+		}
+		t.Logf("%s: scale %d, max CDF error after round trip %.4f, against exact CDF %.4f", test.name, e.Scale, maxerr, maxexact)
+		if maxerr > test.tolerance {
+			t.Errorf("%s: CDF error after round trip %v", test.name, maxerr)
+		}
+	}
+}
+
+func TestExponentialSum(t *testing.T) {
+	// Bucket counts estimated from wide bins must hold Sum within the
+	// buckets, so that it is preserved without leaving the range of the data.
+	for seed := int64(0); seed < 200; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		h := NewHistogram(64, 1)
+		for i := 0; i < 300; i++ {
+			h.Add([]float64{math.Exp(r.NormFloat64())})
+		}
+
+		e, err := ToExponential(h, 160)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := NewHistogramFromExponential(64, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum := u.Mean()[0] * u.Count(); math.Abs(sum-e.Sum) > 1e-9*e.Sum || u.Count() != h.Count() {
+			t.Errorf("Seed %d: Sum %v or count %v mismatch after round trip, expected %v and %v", seed, sum, u.Count(), e.Sum, h.Count())
+		}
+		// Buckets extend the range by at most one bucket width.
+		base := math.Exp2(math.Ldexp(1, -int(e.Scale)))
+		if min, max := u.Min(), u.Max(); min[0] < h.Min()[0]/base || max[0] > h.Max()[0]*base {
+			t.Errorf("Seed %d: range %v %v after round trip, expected %v %v", seed, min, max, h.Min(), h.Max())
+		}
+	}
+
+	// Values never leave their buckets, even if Sum does not fit.
+	h, err := NewHistogramFromExponential(16, ExponentialHistogram{
+		Count:    10,
+		Sum:      100,
+		Positive: ExponentialBuckets{Offset: 0, BucketCounts: []uint64{10}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min, max := h.Min(), h.Max(); min[0] != 1 || max[0] != 2 {
+		t.Errorf("Range %v %v outside the bucket (1, 2]", min, max)
+	}
+	if q := h.Quantile(0.95); q[0] > 2 {
+		t.Errorf("Quantile 0.95 %v outside the bucket (1, 2]", q)
+	}
+}
+
+func TestExponential(t *testing.T) {
+	// Scale 0 buckets are (1, 2], (2, 4] and (4, 8].
+	e := ExponentialHistogram{
+		Count:     10,
+		Sum:       25,
+		ZeroCount: 1,
+		Positive:  ExponentialBuckets{Offset: 0, BucketCounts: []uint64{2, 3, 3}},
+		Negative:  ExponentialBuckets{Offset: 1, BucketCounts: []uint64{1}},
+	}
+	h, err := NewHistogramFromExponential(16, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Count() != 10 || !approx(h.Mean()[0]*h.Count(), 25) {
+		t.Errorf("Count %v or sum %v mismatch", h.Count(), h.Mean()[0]*h.Count())
+	}
+	if min, max := h.Min(), h.Max(); min[0] != -4 || max[0] != 8 {
+		t.Errorf("Range mismatch %v %v", min, max)
+	}
+	if cdf := h.CDF([]float64{2}); !approx(cdf, 0.4) {
+		t.Errorf("CDF(2) %v != 0.4", cdf)
+	}
+
+	r, err := ToExponential(h, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Scale != 0 || r.ZeroCount != 1 || r.Positive.Offset != 0 || r.Negative.Offset != 1 {
+		t.Errorf("Unexpected exponential histogram %+v", r)
+	}
+	for i, c := range []uint64{2, 3, 3} {
+		if r.Positive.BucketCounts[i] != c {
+			t.Errorf("Positive bucket counts %v != [2 3 3]", r.Positive.BucketCounts)
+		}
+	}
+
+	e.Count = 11
+	if _, err := NewHistogramFromExponential(16, e); err == nil {
+		t.Errorf("Expected error for inconsistent count")
+	}
+	if _, err := NewHistogramFromExponential(0, r); err == nil {
+		t.Errorf("Expected error for 0 bins")
+	}
+	if _, err := ToExponential(NewHistogram(16, 2), 160); err == nil {
+		t.Errorf("Expected error for dimension 2")
+	}
+}
@@ -0,0 +1,174 @@
+package histogram
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Handler serves the histograms of a Registry as JSON:
+//
+//	GET /                    names and labels of all series
+//	GET /{name}?q=0.5&cdf=x  count, mean, variance, min, max, the quantiles q
+//	                         and the CDF at the comma separated points x
+//	GET /{name}/state        the histogram as marshalled by json.Marshal
+//
+// Both q and cdf may be repeated. All other query parameters select the
+// labels of the series, e.g. /latency?route=/api&q=0.99.
+type Handler struct {
+	Registry *Registry
+}
+
+// NewHandler returns a handler serving r.
+func NewHandler(r *Registry) *Handler {
+	return &Handler{Registry: r}
+}
+
+type seriesJSON struct {
+	Name   string `json:"name"`
+	Labels Labels `json:"labels"`
+}
+
+type summaryJSON struct {
+	Name      string         `json:"name"`
+	Labels    Labels         `json:"labels"`
+	Count     float64        `json:"count"`
+	Mean      []float64      `json:"mean"`
+	Variance  []float64      `json:"variance"`
+	Min       []float64      `json:"min"`
+	Max       []float64      `json:"max"`
+	Quantiles []quantileJSON `json:"quantiles,omitempty"`
+	CDF       []cdfJSON      `json:"cdf,omitempty"`
+}
+
+type quantileJSON struct {
+	Q     float64   `json:"q"`
+	Value []float64 `json:"value"`
+}
+
+// cdfJSON keeps the point as given, it may contain infinities. The value is
+// null for an empty histogram.
+type cdfJSON struct {
+	X     string   `json:"x"`
+	Value *float64 `json:"value"`
+}
+
+func (hd *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "" {
+		list := []seriesJSON{}
+		for _, s := range hd.Registry.All() {
+			list = append(list, seriesJSON{Name: s.Name, Labels: s.Labels})
+		}
+		writeJSON(w, list)
+		return
+	}
+
+	query := r.URL.Query()
+	labels := Labels{}
+	for k, v := range query {
+		if k != "q" && k != "cdf" {
+			labels[k] = v[0]
+		}
+	}
+
+	name, state := path, false
+	if strings.HasSuffix(path, "/state") {
+		name, state = strings.TrimSuffix(path, "/state"), true
+	}
+	s := hd.Registry.Lookup(name, labels)
+	if s == nil {
+		http.Error(w, fmt.Sprintf("histogram %s not found", seriesKey(name, labels)), http.StatusNotFound)
+		return
+	}
+
+	if state {
+		var data []byte
+		var err error
+		s.Do(func(h Histogram) { data, err = json.Marshal(h) })
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+
+	quantiles := make([]float64, len(query["q"]))
+	for i, v := range query["q"] {
+		q, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(q >= 0 && q <= 1) {
+			http.Error(w, fmt.Sprintf("invalid quantile %q", v), http.StatusBadRequest)
+			return
+		}
+		quantiles[i] = q
+	}
+	points := make([][]float64, len(query["cdf"]))
+	for i, v := range query["cdf"] {
+		for _, field := range strings.Split(v, ",") {
+			x, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil || math.IsNaN(x) {
+				http.Error(w, fmt.Sprintf("invalid CDF point %q", v), http.StatusBadRequest)
+				return
+			}
+			points[i] = append(points[i], x)
+		}
+	}
+
+	var summary summaryJSON
+	var err error
+	s.Do(func(h Histogram) {
+		for i, x := range points {
+			if len(x) != h.Dimension() {
+				err = fmt.Errorf("CDF point %q has dimension %d, expected %d", query["cdf"][i], len(x), h.Dimension())
+				return
+			}
+		}
+
+		summary = summaryJSON{
+			Name:     name,
+			Labels:   s.Labels,
+			Count:    h.Count(),
+			Mean:     h.Mean(),
+			Variance: h.Variance(),
+			Min:      h.Min(),
+			Max:      h.Max(),
+		}
+		for _, q := range quantiles {
+			summary.Quantiles = append(summary.Quantiles, quantileJSON{Q: q, Value: MarginalQuantile(h, q)})
+		}
+		for i, x := range points {
+			c := cdfJSON{X: query["cdf"][i]}
+			if h.Count() > 0 {
+				value := h.CDF(x)
+				c.Value = &value
+			}
+			summary.CDF = append(summary.CDF, c)
+		}
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, summary)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,119 @@
+package histogram
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry(16, 2)
+	for i := 0; i < 100; i++ {
+		r.Get("latency", nil).Add([]float64{float64(i), float64(i % 10)})
+	}
+	r.Get("latency", Labels{"route": "/api"}).Add([]float64{1, 2})
+	r.Get("empty", nil)
+
+	server := httptest.NewServer(NewHandler(r))
+	defer server.Close()
+
+	var list []seriesJSON
+	get(t, server.URL+"/", http.StatusOK, &list)
+	if len(list) != 3 || list[0].Name != "empty" || list[1].Name != "latency" || list[2].Labels["route"] != "/api" {
+		t.Errorf("Unexpected series %v", list)
+	}
+
+	var summary summaryJSON
+	get(t, server.URL+"/latency?q=0.5&q=1&cdf=49.5,%2BInf&cdf=-1,-1", http.StatusOK, &summary)
+	if summary.Count != 100 || !approx(summary.Mean[0], 49.5) || summary.Max[0] != 99 || summary.Min[1] != 0 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if len(summary.Quantiles) != 2 || summary.Quantiles[1].Value[0] != 99 {
+		t.Errorf("Unexpected quantiles %+v", summary.Quantiles)
+	}
+	if len(summary.CDF) != 2 || summary.CDF[0].X != "49.5,+Inf" || !approx2(*summary.CDF[0].Value, 0.5) || *summary.CDF[1].Value != 0 {
+		t.Errorf("Unexpected CDF %+v", summary.CDF)
+	}
+
+	summary = summaryJSON{}
+	get(t, server.URL+"/latency?route=/api&q=0.5", http.StatusOK, &summary)
+	if summary.Count != 1 || summary.Labels["route"] != "/api" || summary.Quantiles[0].Value[0] != 1 {
+		t.Errorf("Unexpected labelled summary %+v", summary)
+	}
+
+	summary = summaryJSON{}
+	get(t, server.URL+"/empty?cdf=1,1", http.StatusOK, &summary)
+	if summary.Count != 0 || summary.CDF[0].Value != nil {
+		t.Errorf("Unexpected empty summary %+v", summary)
+	}
+
+	var state json.RawMessage
+	get(t, server.URL+"/latency/state", http.StatusOK, &state)
+	h, err := Unmarshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Count() != 100 {
+		t.Errorf("Count mismatch in state %v != 100", h.Count())
+	}
+
+	get(t, server.URL+"/missing", http.StatusNotFound, nil)
+	get(t, server.URL+"/latency?route=/missing", http.StatusNotFound, nil)
+	get(t, server.URL+"/latency?q=2", http.StatusBadRequest, nil)
+	get(t, server.URL+"/latency?cdf=1", http.StatusBadRequest, nil)
+	get(t, server.URL+"/latency?cdf=a,b", http.StatusBadRequest, nil)
+
+	resp, err := http.Post(server.URL+"/latency", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+}
+
+func TestRegistryConcurrency(t *testing.T) {
+	r := NewRegistry(16, 1)
+	handler := NewHandler(r)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Get("concurrent", nil).Add([]float64{float64(j)})
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest("GET", "/concurrent?q=0.5", nil))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	r.Get("concurrent", nil).Do(func(h Histogram) {
+		if h.Count() != 400 {
+			t.Errorf("Count mismatch %v != 400", h.Count())
+		}
+	})
+}
+
+func get(t *testing.T, url string, status int, v interface{}) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != status {
+		t.Errorf("GET %s: expected status %d, got %d", url, status, resp.StatusCode)
+		return
+	}
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Errorf("GET %s: %v", url, err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package histogram
 
 import (
 	"fmt"
+	"math"
+	sortpkg "sort"
 )
 
 type Histogram interface {
@@ -11,19 +13,42 @@ type Histogram interface {
 
 	Variance() []float64
 
+	Min() []float64
+
+	Max() []float64
+
 	CDF(x []float64) float64
 
 	Quantile(q float64) []float64
 
+	// Merge adds the points of o. It is a no-op if o has a different
+	// dimension or is of a kind whose points the histogram cannot take, such
+	// as a non-exact histogram merged into an exact one; compare Count before
+	// and after to detect it.
+	Merge(o Histogram)
+
+	Probability(lo, hi []float64) float64
+
+	CDFWithBounds(x []float64) (lower, upper float64)
+
+	QuantileWithBounds(q float64) (lower, upper []float64)
+
 	String() (str string)
 
 	Count() float64
+
+	Dimension() int
+}
+
+// boxer is implemented by histograms that describe their mass as bins.
+type boxer interface {
+	boxes() []bin
 }
 
 type histogram struct {
 	bins      []bin
 	maxbins   int
-	total     uint64
+	total     float64
 	dimension int
 }
 
@@ -44,13 +69,40 @@ func (h *histogram) Add(values []float64) {
 		return
 	}
 	h.total++
+	b := bin{count: 1, vec: m, variance: v, min: m, max: m}
 	for i := range h.bins {
-		if h.bins[i].vec.Equals(v) {
-			h.bins[i].count++
+		if h.bins[i].vec.Equals(m) {
+			h.bins[i] = h.bins[i].Merge(b)
 			return
 		}
 	}
-	h.bins = append(h.bins, bin{count: 1, vec: m, variance: v, min: m, max: m})
+	h.bins = append(h.bins, b)
+	h.trim()
+}
+
+// Merge adds the bins of o to h, merging bins as needed to stay within the
+// maximum bin count. It does nothing if o has a different dimension or does
+// not describe its mass as bins.
+func (h *histogram) Merge(o Histogram) {
+	b, ok := o.(boxer)
+	if !ok || o.Dimension() != h.dimension {
+		return
+	}
+
+	bins := make([]bin, len(b.boxes()))
+	copy(bins, b.boxes())
+	for i := range bins {
+		h.insert(bins[i])
+	}
+	h.total += o.Count()
+}
+
+// insert adds a bin without updating the total.
+func (h *histogram) insert(b bin) {
+	if b.count <= 0 {
+		return
+	}
+	h.bins = append(h.bins, b)
 	h.trim()
 }
 
@@ -68,7 +120,7 @@ func (h *histogram) Mean() []float64 {
 	}
 
 	for k, s := range sum {
-		s = s / float64(h.total)
+		s = s / h.total
 		sum[k] = s
 	}
 	return sum
@@ -90,12 +142,42 @@ func (h *histogram) Variance() []float64 {
 	}
 
 	for k, _ := range sum {
-		sum[k] = sum[k] / float64(h.total)
+		sum[k] = sum[k] / h.total
 		sum[k] = sum[k] - mean[k]*mean[k]
 	}
 	return sum
 }
 
+func (h *histogram) Min() []float64 {
+	if h.total == 0 {
+		return []float64{}
+	}
+
+	r := make([]float64, h.dimension)
+	copy(r, h.bins[0].min.Values())
+	for i := range h.bins {
+		for j := range r {
+			r[j] = min(r[j], h.bins[i].min.Value(j))
+		}
+	}
+	return r
+}
+
+func (h *histogram) Max() []float64 {
+	if h.total == 0 {
+		return []float64{}
+	}
+
+	r := make([]float64, h.dimension)
+	copy(r, h.bins[0].max.Values())
+	for i := range h.bins {
+		for j := range r {
+			r[j] = max(r[j], h.bins[i].max.Value(j))
+		}
+	}
+	return r
+}
+
 func (h *histogram) Quantile(q float64) []float64 {
 	count := q * float64(h.total)
 	for i := range h.bins {
@@ -109,6 +191,73 @@ func (h *histogram) Quantile(q float64) []float64 {
 	return []float64{}
 }
 
+// MarginalQuantile returns, per dimension, the q-th quantile of the marginal
+// distribution of h, inverting the marginal CDF of its bins. The marginal CDF
+// is piecewise linear between the bin boundaries, with jumps at singleton
+// bins. Unlike Quantile, which returns the centroid of the bin where the
+// cumulative count reaches q, the result does not depend on the order of the
+// bins. Histograms that do not describe their mass as bins return their own
+// Quantile. MarginalQuantile returns an empty slice for an empty histogram.
+func MarginalQuantile(h Histogram, q float64) []float64 {
+	b, ok := h.(boxer)
+	if !ok {
+		return h.Quantile(q)
+	}
+	if h.Count() == 0 {
+		return []float64{}
+	}
+
+	bins := b.boxes()
+	r := make([]float64, h.Dimension())
+	for j := range r {
+		r[j] = marginalQuantile(bins, j, q)
+	}
+	return r
+}
+
+// marginalQuantile inverts the marginal CDF of dimension j of bins.
+func marginalQuantile(bins []bin, j int, q float64) float64 {
+	breaks := make([]float64, 0, 2*len(bins))
+	for i := range bins {
+		breaks = append(breaks, bins[i].min.Value(j), bins[i].max.Value(j))
+	}
+	sortpkg.Float64s(breaks)
+
+	k := sortpkg.Search(len(breaks), func(k int) bool { return marginalCDF(bins, j, breaks[k], true) >= q })
+	if k == 0 {
+		return breaks[0]
+	}
+	if k == len(breaks) {
+		return breaks[len(breaks)-1]
+	}
+
+	// Left limit at breaks[k]; q at or above it falls on a jump.
+	upper := marginalCDF(bins, j, breaks[k], false)
+	if q >= upper {
+		return breaks[k]
+	}
+	lower := marginalCDF(bins, j, breaks[k-1], true)
+	return breaks[k-1] + (q-lower)/(upper-lower)*(breaks[k]-breaks[k-1])
+}
+
+// marginalCDF returns the fraction of the points of bins with a value in
+// dimension j at most x. Points of singleton bins at x are only counted when
+// inclusive.
+func marginalCDF(bins []bin, j int, x float64, inclusive bool) float64 {
+	sum, total := 0.0, 0.0
+	for i := range bins {
+		min := bins[i].min.Value(j)
+		max := bins[i].max.Value(j)
+		total += bins[i].count
+		if x > max || (inclusive && x == max) {
+			sum += bins[i].count
+		} else if x > min {
+			sum += bins[i].count * (x - min) / (max - min)
+		}
+	}
+	return sum / total
+}
+
 func (h *histogram) CDF(x []float64) float64 {
 	// fmt.Println(x)
 	xVec := NewVector(x)
@@ -140,27 +289,136 @@ func (h *histogram) CDF(x []float64) float64 {
 		sum += count
 	}
 
-	return sum / float64(h.total)
+	return sum / h.total
+}
+
+// Probability returns the fraction of points in the box lo < x <= hi, so that
+// CDF(x) equals Probability with lo at -Inf. Infinite bounds select
+// marginals.
+func (h *histogram) Probability(lo, hi []float64) float64 {
+	if len(lo) != h.dimension || len(hi) != h.dimension {
+		return -1
+	}
+
+	sum := 0.0
+	for i := range h.bins {
+		count := h.bins[i].count
+		for j := 0; j < h.dimension && count > 0; j++ {
+			count *= overlap(lo[j], hi[j], h.bins[i].min.Value(j), h.bins[i].max.Value(j))
+		}
+		sum += count
+	}
+
+	return sum / h.total
+}
+
+// overlap returns the fraction of [min, max] within (lo, hi], assuming points
+// are uniform within [min, max].
+func overlap(lo, hi, min, max float64) float64 {
+	if min == max {
+		if lo < min && min <= hi {
+			return 1
+		}
+		return 0
+	}
+
+	r := (math.Min(hi, max) - math.Max(lo, min)) / (max - min)
+	if r < 0 {
+		return 0
+	}
+	return r
+}
+
+// CDFWithBounds returns the best and worst case CDF at x. Bins whose boxes lie
+// entirely below x count towards both bounds, bins whose boxes straddle x only
+// count towards the upper bound.
+func (h *histogram) CDFWithBounds(x []float64) (lower, upper float64) {
+	xVec := NewVector(x)
+	if xVec.Dimension() != h.dimension {
+		return -1, -1
+	}
+	if h.total == 0 {
+		return 0, 0
+	}
+
+	for i := range h.bins {
+		below, straddles := true, false
+		for j := 0; j < h.dimension; j++ {
+			x := xVec.Value(j)
+			if x < h.bins[i].min.Value(j) {
+				below, straddles = false, false
+				break
+			}
+			if x < h.bins[i].max.Value(j) {
+				below, straddles = false, true
+			}
+		}
+		if below {
+			lower += h.bins[i].count
+			upper += h.bins[i].count
+		} else if straddles {
+			upper += h.bins[i].count
+		}
+	}
+
+	return lower / h.total, upper / h.total
+}
+
+// QuantileWithBounds returns, per dimension, the range in which the q-th
+// quantile of the marginal distribution must lie. The lower bound assumes all
+// points of a bin sit at its min, the upper bound assumes they sit at its max.
+func (h *histogram) QuantileWithBounds(q float64) (lower, upper []float64) {
+	if h.total == 0 {
+		return []float64{}, []float64{}
+	}
+
+	lower = make([]float64, h.dimension)
+	upper = make([]float64, h.dimension)
+	for j := 0; j < h.dimension; j++ {
+		lower[j] = h.quantileAt(q, func(b bin) float64 { return b.min.Value(j) })
+		upper[j] = h.quantileAt(q, func(b bin) float64 { return b.max.Value(j) })
+	}
+	return
+}
+
+// quantileAt returns the q-th quantile assuming the points of every bin are
+// located at position(bin).
+func (h *histogram) quantileAt(q float64, position func(b bin) float64) float64 {
+	bins := make([]bin, len(h.bins))
+	copy(bins, h.bins)
+	sortpkg.Slice(bins, func(i, j int) bool { return position(bins[i]) < position(bins[j]) })
+
+	count := q * h.total
+	for i := range bins {
+		count -= bins[i].count
+
+		if count <= 0 {
+			return position(bins[i])
+		}
+	}
+	return position(bins[len(bins)-1])
 }
 
 func (h *histogram) String() (str string) {
 	str += fmt.Sprintln("Total:", h.total)
 
 	for i := range h.bins {
-		var bar string
-		// for j := 0; j < int(float64(h.bins[i].count)/float64(h.total)*200); j++ {
-		for j := 0; j < int(h.bins[i].count); j++ {
-			bar += "."
-		}
-		// str += fmt.Sprintln(h.bins[i].vec.String(), "\t", bar)
-		str += fmt.Sprintln(h.bins[i].vec.String(), h.bins[i].min.String(), h.bins[i].max.String(), "\t", h.Count())
+		str += fmt.Sprintln(h.bins[i].vec.String(), h.bins[i].min.String(), h.bins[i].max.String(), "\t", h.bins[i].count)
 	}
 
 	return
 }
 
 func (h *histogram) Count() float64 {
-	return float64(h.total)
+	return h.total
+}
+
+func (h *histogram) Dimension() int {
+	return h.dimension
+}
+
+func (h *histogram) boxes() []bin {
+	return h.bins
 }
 
 // ==============================================================================
@@ -2,6 +2,7 @@ package histogram
 
 import (
 	// "fmt"
+	"math"
 	"math/rand"
 	"testing"
 )
@@ -98,3 +99,230 @@ func benchmarkHistogramBins(d int, n int, t *testing.B) {
 		}
 	}
 }
+
+func TestAddDuplicates(t *testing.T) {
+	// Add used to compare bins with the zero vector instead of the point, so
+	// every point added after a point at the origin only incremented the
+	// count of its bin and was lost from the mean and bounds.
+	h := NewHistogram(8, 1)
+	for _, v := range []float64{0, 5, 5} {
+		h.Add([]float64{v})
+	}
+	if h.Count() != 3 {
+		t.Errorf("Count mismatch %v != 3", h.Count())
+	}
+	if mean := h.Mean(); !approx(mean[0], 10.0/3) {
+		t.Errorf("Mean mismatch %v != %v", mean, 10.0/3)
+	}
+	if max := h.Max(); max[0] != 5 {
+		t.Errorf("Max mismatch %v != 5", max)
+	}
+
+	// Exact duplicates share a bin.
+	if bins := h.(*histogram).bins; len(bins) != 2 || bins[1].count != 2 {
+		t.Errorf("Duplicates not merged %v", bins)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	for _, b := range []int{2, 5, 10, 50} {
+
+		for _, d := range []int{1, 2, 3} {
+
+			h := NewHistogram(b, d)
+			e := NewExactHistogram(d)
+
+			for j := 0; j < 500; j++ {
+				var values = []float64{}
+				for i := 0; i < d; i++ {
+					values = append(values, rand.NormFloat64()*100)
+				}
+				h.Add(values)
+				e.Add(values)
+			}
+
+			for _, q := range []float64{0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99} {
+				x := make([]float64, d)
+				for i := range x {
+					x[i] = rand.NormFloat64() * 100
+				}
+
+				cdf := h.CDF(x)
+				exact := e.CDF(x)
+				lower, upper := h.CDFWithBounds(x)
+				if lower > exact || exact > upper {
+					t.Errorf("Exact CDF %v outside bounds [%v, %v]", exact, lower, upper)
+				}
+				if lower > cdf+1e-9 || cdf > upper+1e-9 {
+					t.Errorf("Estimated CDF %v outside bounds [%v, %v]", cdf, lower, upper)
+				}
+
+				qlower, qupper := h.QuantileWithBounds(q)
+				quantile := MarginalQuantile(h, q)
+				exactQuantile := e.Quantile(q)
+				for i := 0; i < d; i++ {
+					if qlower[i] > quantile[i]+1e-9 || quantile[i] > qupper[i]+1e-9 {
+						t.Errorf("Estimated quantile %v of dimension %d %v outside bounds [%v, %v]", q, i, quantile[i], qlower[i], qupper[i])
+					}
+					if qlower[i] > exactQuantile[i] || exactQuantile[i] > qupper[i] {
+						t.Errorf("Quantile %v of dimension %d %v outside bounds [%v, %v]", q, i, exactQuantile[i], qlower[i], qupper[i])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMarginalQuantile(t *testing.T) {
+	h := NewHistogram(100, 1)
+	var sample = []float64{}
+	for i := 0; i < 1000; i++ {
+		v := rand.Float64()
+		sample = append(sample, v)
+		h.Add([]float64{v})
+	}
+
+	for _, q := range []float64{0, 0.1, 0.25, 0.5, 0.75, 0.9, 1} {
+		quantile := MarginalQuantile(h, q)
+		// Rank of the estimate within the sample.
+		rank := 0.0
+		for _, v := range sample {
+			if v <= quantile[0] {
+				rank += 1 / float64(len(sample))
+			}
+		}
+		if math.Abs(rank-q) > 0.03 {
+			t.Errorf("Quantile %v incorrect %v, rank %v", q, quantile, rank)
+		}
+		if cdf := h.CDF(quantile); !approx(cdf, q) {
+			t.Errorf("CDF of quantile %v incorrect %v", q, cdf)
+		}
+	}
+
+	min, max := h.Min(), h.Max()
+	if quantile := MarginalQuantile(h, 0); quantile[0] != min[0] {
+		t.Errorf("Quantile 0 %v != Min %v", quantile, min)
+	}
+	if quantile := MarginalQuantile(h, 1); quantile[0] != max[0] {
+		t.Errorf("Quantile 1 %v != Max %v", quantile, max)
+	}
+
+	h = NewHistogram(3, 1)
+	for _, v := range []float64{1, 2, 2, 3} {
+		h.Add([]float64{v})
+	}
+	if quantile := MarginalQuantile(h, 0.5); quantile[0] != 2 {
+		t.Errorf("Median of singleton bins incorrect %v", quantile)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	for _, d := range []int{1, 2, 5} {
+		h := NewHistogram(10, d)
+		o := NewHistogram(10, d)
+		all := NewHistogram(20, d)
+
+		for j := 0; j < 200; j++ {
+			var values = []float64{}
+			for i := 0; i < d; i++ {
+				values = append(values, rand.NormFloat64())
+			}
+			all.Add(values)
+			if j%2 == 0 {
+				h.Add(values)
+			} else {
+				o.Add(values)
+			}
+		}
+
+		h.Merge(o)
+		if h.Count() != all.Count() {
+			t.Errorf("Count mismatch %v != %v", h.Count(), all.Count())
+		}
+		for i := 0; i < d; i++ {
+			if !approx(h.Mean()[i], all.Mean()[i]) {
+				t.Errorf("Mean mismatch %v != %v", h.Mean(), all.Mean())
+			}
+			if !approx(h.Variance()[i], all.Variance()[i]) {
+				t.Errorf("Variance mismatch %v != %v", h.Variance(), all.Variance())
+			}
+			if h.Min()[i] != all.Min()[i] || h.Max()[i] != all.Max()[i] {
+				t.Errorf("Range mismatch %v %v != %v %v", h.Min(), h.Max(), all.Min(), all.Max())
+			}
+		}
+		if len(h.(*histogram).bins) > 10 {
+			t.Errorf("Merged histogram has %d bins", len(h.(*histogram).bins))
+		}
+
+		// Merging a histogram of another dimension is ignored.
+		other := NewHistogram(10, d+1)
+		other.Add(make([]float64, d+1))
+		h.Merge(other)
+		if h.Count() != all.Count() {
+			t.Errorf("Count changed by merging dimension %d", d+1)
+		}
+
+		// Merging with itself doubles the counts.
+		h.Merge(h)
+		if h.Count() != 2*all.Count() {
+			t.Errorf("Count mismatch after self merge %v != %v", h.Count(), 2*all.Count())
+		}
+	}
+}
+
+func TestProbability(t *testing.T) {
+	for _, d := range []int{1, 2, 3} {
+		h := NewHistogram(20, d)
+		for j := 0; j < 500; j++ {
+			var values = []float64{}
+			for i := 0; i < d; i++ {
+				values = append(values, float64(rand.Intn(100)))
+			}
+			h.Add(values)
+		}
+
+		lo, hi := make([]float64, d), make([]float64, d)
+		inf, ninf := make([]float64, d), make([]float64, d)
+		for i := 0; i < d; i++ {
+			lo[i], hi[i] = 25, 75
+			inf[i], ninf[i] = math.Inf(1), math.Inf(-1)
+		}
+
+		if p := h.Probability(ninf, inf); !approx(p, 1) {
+			t.Errorf("Probability of everything %v != 1", p)
+		}
+		if p, cdf := h.Probability(ninf, hi), h.CDF(hi); !approx(p, cdf) {
+			t.Errorf("Probability below %v %v != CDF %v", hi, p, cdf)
+		}
+
+		// Inclusion-exclusion of the CDF over the corners of the box.
+		expected := 0.0
+		for corner := 0; corner < 1<<uint(d); corner++ {
+			x := make([]float64, d)
+			sign := 1.0
+			for i := 0; i < d; i++ {
+				if corner&(1<<uint(i)) != 0 {
+					x[i] = lo[i]
+					sign = -sign
+				} else {
+					x[i] = hi[i]
+				}
+			}
+			expected += sign * h.CDF(x)
+		}
+		if p := h.Probability(lo, hi); !approx(p, expected) {
+			t.Errorf("Probability of box %v != %v", p, expected)
+		}
+	}
+
+	h := NewHistogram(10, 1)
+	for _, v := range []float64{1, 2, 3, 4} {
+		h.Add([]float64{v})
+	}
+	if p := h.Probability([]float64{2}, []float64{4}); p != 0.5 {
+		t.Errorf("Probability of half-open interval %v != 0.5", p)
+	}
+	if p := h.Probability([]float64{2}, []float64{4, 5}); p != -1 {
+		t.Errorf("Expected -1 for dimension mismatch, got %v", p)
+	}
+}
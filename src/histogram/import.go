@@ -0,0 +1,104 @@
+package histogram
+
+import (
+	"fmt"
+	"math"
+)
+
+// NewHistogramFromBuckets builds a 1-D histogram of at most n bins from fixed
+// buckets, where counts[i] is the number of values in the bucket between
+// boundaries[i] and boundaries[i+1]. Cumulative counts, as exported by
+// Prometheus, must be differenced first.
+func NewHistogramFromBuckets(n int, boundaries []float64, counts []float64) (Histogram, error) {
+	return NewHistogramFromGrid(n, [][]float64{boundaries}, counts)
+}
+
+// NewHistogramFromGrid builds a histogram of at most n bins from a grid of
+// buckets. boundaries holds the bucket boundaries of every dimension and
+// counts the count of every grid cell in row-major order, the last dimension
+// varying fastest.
+//
+// Values are assumed uniform within each bucket, giving every bin the bucket
+// center as centroid and width²/12 as variance. Buckets with an infinite
+// boundary, such as the +Inf bucket of Prometheus, are collapsed onto their
+// finite boundary.
+func NewHistogramFromGrid(n int, boundaries [][]float64, counts []float64) (Histogram, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("histogram: invalid bin count %d", n)
+	}
+	d := len(boundaries)
+	if d == 0 {
+		return nil, fmt.Errorf("histogram: no boundaries")
+	}
+
+	cells := 1
+	for k, edges := range boundaries {
+		if len(edges) < 2 {
+			return nil, fmt.Errorf("histogram: dimension %d needs at least 2 boundaries", k)
+		}
+		for i := 1; i < len(edges); i++ {
+			if !(edges[i] > edges[i-1]) {
+				return nil, fmt.Errorf("histogram: boundaries of dimension %d not strictly increasing", k)
+			}
+		}
+		if math.IsInf(edges[0], -1) && math.IsInf(edges[len(edges)-1], 1) && len(edges) == 2 {
+			return nil, fmt.Errorf("histogram: dimension %d has no finite boundary", k)
+		}
+		cells *= len(edges) - 1
+	}
+	if len(counts) != cells {
+		return nil, fmt.Errorf("histogram: %d counts for %d buckets", len(counts), cells)
+	}
+
+	h := NewHistogram(n, d).(*histogram)
+	index := make([]int, d)
+	for _, count := range counts {
+		if count < 0 || math.IsNaN(count) || math.IsInf(count, 0) {
+			return nil, fmt.Errorf("histogram: invalid count %v", count)
+		}
+		if count > 0 {
+			h.insert(bucket(boundaries, index, count))
+			h.total += count
+		}
+
+		// Advance the grid index, last dimension fastest.
+		for k := d - 1; k >= 0; k-- {
+			index[k]++
+			if index[k] < len(boundaries[k])-1 {
+				break
+			}
+			index[k] = 0
+		}
+	}
+	return h, nil
+}
+
+// bucket returns the bin of the grid cell at index, uniform within its box.
+func bucket(boundaries [][]float64, index []int, count float64) bin {
+	d := len(boundaries)
+	mean := make([]float64, d)
+	variance := make([]float64, d)
+	min := make([]float64, d)
+	max := make([]float64, d)
+
+	for k := range boundaries {
+		lo, hi := boundaries[k][index[k]], boundaries[k][index[k]+1]
+		if math.IsInf(lo, -1) {
+			lo = hi
+		}
+		if math.IsInf(hi, 1) {
+			hi = lo
+		}
+		min[k], max[k] = lo, hi
+		mean[k] = (lo + hi) / 2
+		variance[k] = square(hi-lo) / 12
+	}
+
+	return bin{
+		vec:      NewVector(mean),
+		variance: NewVector(variance),
+		count:    count,
+		min:      NewVector(min),
+		max:      NewVector(max),
+	}
+}
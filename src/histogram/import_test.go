@@ -0,0 +1,118 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewHistogramFromBuckets(t *testing.T) {
+	boundaries := []float64{0, 1, 2, 4, 8}
+	counts := []float64{10, 0, 20, 10}
+
+	h, err := NewHistogramFromBuckets(16, boundaries, counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h.Count() != 40 {
+		t.Errorf("Count mismatch %v != 40", h.Count())
+	}
+	// Uniform within buckets: mean 0.25*0.5 + 0.5*3 + 0.25*6.
+	if mean := h.Mean(); !approx(mean[0], 3.125) {
+		t.Errorf("Mean mismatch %v != 3.125", mean)
+	}
+	// E[X²] of a uniform bucket is its center² + width²/12.
+	ex2 := (10*(0.25+1.0/12) + 20*(9+4.0/12) + 10*(36+16.0/12)) / 40
+	if variance := h.Variance(); !approx(variance[0], ex2-3.125*3.125) {
+		t.Errorf("Variance mismatch %v != %v", variance, ex2-3.125*3.125)
+	}
+	for i, expected := range []float64{0, 0.25, 0.25, 0.75, 1} {
+		if cdf := h.CDF([]float64{boundaries[i]}); !approx(cdf, expected) {
+			t.Errorf("CDF(%v) mismatch %v != %v", boundaries[i], cdf, expected)
+		}
+	}
+	if cdf := h.CDF([]float64{3}); !approx(cdf, 0.5) {
+		t.Errorf("CDF(3) mismatch %v != 0.5", cdf)
+	}
+	if q := MarginalQuantile(h, 0.5); !approx(q[0], 3) {
+		t.Errorf("Median mismatch %v != 3", q)
+	}
+
+	// Infinite buckets collapse onto their finite boundary.
+	h, err = NewHistogramFromBuckets(16, []float64{math.Inf(-1), 1, 2, math.Inf(1)}, []float64{5, 10, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min, max := h.Min(), h.Max(); min[0] != 1 || max[0] != 2 {
+		t.Errorf("Range mismatch %v %v", min, max)
+	}
+	if cdf := h.CDF([]float64{1}); !approx(cdf, 0.25) {
+		t.Errorf("CDF(1) mismatch %v != 0.25", cdf)
+	}
+
+	for _, test := range []struct {
+		boundaries []float64
+		counts     []float64
+	}{
+		{[]float64{0}, []float64{}},
+		{[]float64{0, 1, 1}, []float64{1, 1}},
+		{[]float64{0, 1, 2}, []float64{1}},
+		{[]float64{0, 1}, []float64{-1}},
+		{[]float64{math.Inf(-1), math.Inf(1)}, []float64{1}},
+	} {
+		if _, err := NewHistogramFromBuckets(16, test.boundaries, test.counts); err == nil {
+			t.Errorf("Expected error for %v %v", test.boundaries, test.counts)
+		}
+	}
+	for _, n := range []int{0, -1} {
+		if _, err := NewHistogramFromBuckets(n, []float64{0, 1}, []float64{1}); err == nil {
+			t.Errorf("Expected error for %d bins", n)
+		}
+	}
+}
+
+func TestNewHistogramFromGrid(t *testing.T) {
+	boundaries := [][]float64{{0, 1, 2}, {0, 10, 20, 30}}
+	counts := []float64{
+		1, 2, 3,
+		4, 5, 5,
+	}
+
+	h, err := NewHistogramFromGrid(6, boundaries, counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Count() != 20 {
+		t.Errorf("Count mismatch %v != 20", h.Count())
+	}
+	if cdf := h.CDF([]float64{1, 20}); !approx(cdf, 3.0/20) {
+		t.Errorf("CDF mismatch %v != %v", cdf, 3.0/20)
+	}
+	if cdf := h.CDF([]float64{2, 15}); !approx(cdf, (1+4+(2+5)/2.0)/20) {
+		t.Errorf("CDF mismatch %v != %v", cdf, (1+4+(2+5)/2.0)/20)
+	}
+	if mean := h.Mean(); !approx(mean[0], (6*0.5+14*1.5)/20) || !approx(mean[1], (5*5+7*15+8*25)/20.0) {
+		t.Errorf("Mean mismatch %v", mean)
+	}
+
+	// Imported buckets merge with streamed values.
+	s := NewHistogram(6, 2)
+	for i := 0; i < 20; i++ {
+		s.Add([]float64{rand.Float64() * 2, rand.Float64() * 30})
+	}
+	sum := add(multiply(20, h.Mean()), multiply(20, s.Mean()))
+	s.Merge(h)
+	if s.Count() != 40 {
+		t.Errorf("Count mismatch after Merge %v != 40", s.Count())
+	}
+	for i, m := range s.Mean() {
+		if !approx(m, sum[i]/40) {
+			t.Errorf("Mean mismatch after Merge %v != %v", s.Mean(), sum[i]/40)
+		}
+	}
+
+	if _, err := NewHistogramFromGrid(6, boundaries, counts[1:]); err == nil {
+		t.Errorf("Expected error for missing counts")
+	}
+}
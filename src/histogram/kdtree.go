@@ -0,0 +1,372 @@
+package histogram
+
+import (
+	"fmt"
+	"math"
+	sortpkg "sort"
+)
+
+// kdBins is the number of bins a kd-tree leaf keeps to describe the mass of
+// its cell. Splits clip every bin separately, so the bins keep the shape of the
+// mass within the leaf.
+const kdBins = 8
+
+// kdtree is a histogram over an adaptive kd-tree. Every leaf is a cell of a
+// partition of space holding a small native histogram, whose bins stay within
+// the cell, so unlike the bins of the native histogram only bins of the same
+// cell can overlap. Leaves split when their count exceeds twice the average
+// count per leaf, and when the leaf budget is exhausted the sparsest pair of
+// sibling leaves is merged back into their parent first.
+type kdtree struct {
+	root      *kdnode
+	maxleaves int
+	leaves    int
+	total     float64
+	dimension int
+
+	// summary merges every point, so that moments and ranges are exact.
+	summary bin
+}
+
+type kdnode struct {
+	// Inner nodes send x to left when x[dim] <= split.
+	dim         int
+	split       float64
+	left, right *kdnode
+
+	// Leaves hold the mass of their cell.
+	mass *histogram
+}
+
+// NewKDTree returns a kd-tree histogram of dimension d with at most n leaves,
+// and so at most 8n bins.
+func NewKDTree(n int, d int) Histogram {
+	if n < 1 {
+		n = 1
+	}
+	return &kdtree{
+		root:      newKDLeaf(d),
+		maxleaves: n,
+		leaves:    1,
+		dimension: d,
+	}
+}
+
+func (t *kdtree) Add(values []float64) {
+	if len(values) != t.dimension {
+		return
+	}
+	point := make([]float64, len(values))
+	copy(point, values)
+
+	t.total++
+	t.summary = mergeBins(t.summary, singleton(point))
+
+	leaf := t.root
+	for leaf.left != nil {
+		if point[leaf.dim] <= leaf.split {
+			leaf = leaf.left
+		} else {
+			leaf = leaf.right
+		}
+	}
+	leaf.mass.Add(point)
+	t.balance()
+}
+
+// Merge adds the bins of o, clipping bins that straddle cells.
+func (t *kdtree) Merge(o Histogram) {
+	b, ok := o.(boxer)
+	if !ok || o.Dimension() != t.dimension {
+		return
+	}
+
+	for _, c := range b.boxes() {
+		if c.count > 0 {
+			t.root.insert(c)
+		}
+	}
+	if o.Count() > 0 {
+		t.summary = mergeBins(t.summary, bin{
+			count:    o.Count(),
+			vec:      NewVector(o.Mean()),
+			variance: NewVector(o.Variance()),
+			min:      NewVector(o.Min()),
+			max:      NewVector(o.Max()),
+		})
+	}
+	t.total += o.Count()
+	t.balance()
+}
+
+// insert adds b to the leaves whose cells it overlaps.
+func (n *kdnode) insert(b bin) {
+	if n.left == nil {
+		n.mass.insert(b)
+		n.mass.total += b.count
+		return
+	}
+
+	lo, hi := unbounded(b.vec.Dimension())
+	hi[n.dim] = n.split
+	if left := b.clip(lo, hi); left.count > 0 {
+		n.left.insert(left)
+	}
+	lo[n.dim], hi[n.dim] = n.split, math.Inf(1)
+	if right := b.clip(lo, hi); right.count > 0 {
+		n.right.insert(right)
+	}
+}
+
+// balance splits leaves above the threshold, heaviest first.
+func (t *kdtree) balance() {
+	threshold := 2 * t.total / float64(t.maxleaves)
+
+	// Every split either uses a free leaf or follows a collapse of a pair
+	// lighter than half the split leaf, so this terminates quickly; the bound
+	// guards against cycles on degenerate data.
+	for i := 0; i < t.maxleaves; i++ {
+		leaf := t.root.heaviest()
+		if leaf == nil || leaf.count() <= threshold {
+			return
+		}
+		if t.leaves >= t.maxleaves {
+			parent := t.root.sparsest()
+			if parent == nil || 2*parent.count() >= leaf.count() {
+				return
+			}
+			parent.collapse()
+			t.leaves--
+		}
+		leaf.divide()
+		t.leaves++
+	}
+}
+
+// heaviest returns the leaf with the largest count that can be split.
+func (n *kdnode) heaviest() *kdnode {
+	if n.left == nil {
+		if _, ok := n.widest(); ok {
+			return n
+		}
+		return nil
+	}
+
+	l, r := n.left.heaviest(), n.right.heaviest()
+	if l == nil || (r != nil && r.count() > l.count()) {
+		return r
+	}
+	return l
+}
+
+// sparsest returns the inner node whose children are both leaves with the
+// smallest combined count.
+func (n *kdnode) sparsest() *kdnode {
+	if n.left == nil {
+		return nil
+	}
+	if n.left.left == nil && n.right.left == nil {
+		return n
+	}
+
+	l, r := n.left.sparsest(), n.right.sparsest()
+	if l == nil || (r != nil && r.count() < l.count()) {
+		return r
+	}
+	return l
+}
+
+// collapse turns an inner node with two leaf children back into a leaf.
+func (n *kdnode) collapse() {
+	n.mass = n.left.mass
+	n.mass.Merge(n.right.mass)
+	n.left, n.right = nil, nil
+}
+
+// divide splits a leaf along the dimension in which its box is widest, at the
+// gap between bins nearest to the median bin, or else at the middle of the box.
+func (n *kdnode) divide() {
+	b := n.bin()
+	j, _ := n.widest()
+
+	// Split at the weighted median of the bin means, moved to the nearest gap
+	// between bins so that singleton bins are not cut.
+	bins := make([]bin, len(n.mass.bins))
+	copy(bins, n.mass.bins)
+	sortpkg.Slice(bins, func(a, c int) bool { return bins[a].vec.Value(j) < bins[c].vec.Value(j) })
+
+	median, count := 0, 0.0
+	for median < len(bins)-1 && count+bins[median].count < n.mass.total/2 {
+		count += bins[median].count
+		median++
+	}
+
+	split := (b.min.Value(j) + b.max.Value(j)) / 2
+	for d := 0; d < 2*len(bins); d++ {
+		k := median + d/2*(1-2*(d%2))
+		if k > 0 && k < len(bins) && bins[k-1].vec.Value(j) < bins[k].vec.Value(j) {
+			split = (bins[k-1].vec.Value(j) + bins[k].vec.Value(j)) / 2
+			break
+		}
+	}
+
+	mass := n.mass
+	n.dim, n.split = j, split
+	n.left, n.right = newKDLeaf(mass.dimension), newKDLeaf(mass.dimension)
+	n.mass = nil
+	for i := range mass.bins {
+		n.insert(mass.bins[i])
+	}
+}
+
+// widest returns the dimension in which the box of the leaf is widest, and
+// whether it has a positive width at all.
+func (n *kdnode) widest() (int, bool) {
+	b := n.bin()
+	if b.count == 0 {
+		return 0, false
+	}
+
+	j, width := 0, 0.0
+	for i := 0; i < b.vec.Dimension(); i++ {
+		if w := b.max.Value(i) - b.min.Value(i); w > width {
+			j, width = i, w
+		}
+	}
+	return j, width > 0
+}
+
+// bin returns the mass of a leaf as one bin.
+func (n *kdnode) bin() bin {
+	var b bin
+	for i := range n.mass.bins {
+		b = mergeBins(b, n.mass.bins[i])
+	}
+	return b
+}
+
+func (n *kdnode) count() float64 {
+	if n.left != nil {
+		return n.left.count() + n.right.count()
+	}
+	return n.mass.total
+}
+
+// leaves appends the bins of the leaves below n.
+func (n *kdnode) leaves(bins []bin) []bin {
+	if n.left != nil {
+		return n.right.leaves(n.left.leaves(bins))
+	}
+	return append(bins, n.mass.bins...)
+}
+
+// histogram returns the bins of all leaves as a native histogram, so that
+// queries treat them exactly like native bins.
+func (t *kdtree) histogram() *histogram {
+	return &histogram{
+		bins:      t.boxes(),
+		maxbins:   t.maxleaves,
+		total:     t.total,
+		dimension: t.dimension,
+	}
+}
+
+func (t *kdtree) Mean() []float64 {
+	if t.total == 0 {
+		return []float64{}
+	}
+	return append([]float64{}, t.summary.vec.Values()...)
+}
+
+func (t *kdtree) Variance() []float64 {
+	if t.total == 0 {
+		return []float64{}
+	}
+	return append([]float64{}, t.summary.variance.Values()...)
+}
+
+func (t *kdtree) Min() []float64 {
+	if t.total == 0 {
+		return []float64{}
+	}
+	return append([]float64{}, t.summary.min.Values()...)
+}
+
+func (t *kdtree) Max() []float64 {
+	if t.total == 0 {
+		return []float64{}
+	}
+	return append([]float64{}, t.summary.max.Values()...)
+}
+
+func (t *kdtree) CDF(x []float64) float64 {
+	return t.histogram().CDF(x)
+}
+
+func (t *kdtree) Quantile(q float64) []float64 {
+	return t.histogram().Quantile(q)
+}
+
+func (t *kdtree) Probability(lo, hi []float64) float64 {
+	return t.histogram().Probability(lo, hi)
+}
+
+func (t *kdtree) CDFWithBounds(x []float64) (lower, upper float64) {
+	return t.histogram().CDFWithBounds(x)
+}
+
+func (t *kdtree) QuantileWithBounds(q float64) (lower, upper []float64) {
+	return t.histogram().QuantileWithBounds(q)
+}
+
+func (t *kdtree) String() (str string) {
+	str += fmt.Sprintln("Total:", t.total)
+
+	for _, b := range t.boxes() {
+		str += fmt.Sprintln(b.vec.String(), b.min.String(), b.max.String(), "\t", b.count)
+	}
+
+	return
+}
+
+func (t *kdtree) Count() float64 {
+	return t.total
+}
+
+func (t *kdtree) Dimension() int {
+	return t.dimension
+}
+
+func (t *kdtree) boxes() []bin {
+	return t.root.leaves(make([]bin, 0, t.leaves*kdBins))
+}
+
+func newKDLeaf(d int) *kdnode {
+	return &kdnode{mass: NewHistogram(kdBins, d).(*histogram)}
+}
+
+// singleton returns the bin of a single point.
+func singleton(p []float64) bin {
+	v := NewVector(p)
+	return bin{count: 1, vec: v, variance: NewVector(make([]float64, len(p))), min: v, max: v}
+}
+
+// mergeBins merges two bins, either of which may be empty.
+func mergeBins(a, b bin) bin {
+	if a.count == 0 {
+		return b
+	}
+	if b.count == 0 {
+		return a
+	}
+	return a.Merge(b)
+}
+
+// unbounded returns the bounds of all of space in d dimensions.
+func unbounded(d int) (lo, hi []float64) {
+	lo, hi = make([]float64, d), make([]float64, d)
+	for i := range lo {
+		lo[i], hi[i] = math.Inf(-1), math.Inf(1)
+	}
+	return
+}
@@ -0,0 +1,179 @@
+package histogram
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// correlated returns a 3-D point with correlated, partly skewed coordinates.
+func correlated(r *rand.Rand) []float64 {
+	x := r.NormFloat64()
+	return []float64{x + r.NormFloat64()*0.7, x + r.NormFloat64()*0.7, math.Exp((x + r.NormFloat64()*0.7) / 2)}
+}
+
+func TestKDTree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	k := NewKDTree(64, 3)
+	h := NewHistogram(64, 3)
+	e := NewExactHistogram(3)
+	for i := 0; i < 5000; i++ {
+		v := correlated(r)
+		k.Add(v)
+		h.Add(v)
+		e.Add(v)
+	}
+
+	if k.Count() != e.Count() {
+		t.Errorf("Count %v != %v", k.Count(), e.Count())
+	}
+	for j := 0; j < 3; j++ {
+		if !approx(k.Mean()[j], e.Mean()[j]) || !approx(k.Variance()[j], e.Variance()[j]) || k.Min()[j] != e.Min()[j] || k.Max()[j] != e.Max()[j] {
+			t.Errorf("Moments of dimension %d incorrect %v %v %v %v", j, k.Mean(), k.Variance(), k.Min(), k.Max())
+		}
+	}
+
+	// Bins of different leaves do not overlap.
+	tree := k.(*kdtree)
+	cells := make([][]bin, 0)
+	var walk func(n *kdnode)
+	walk = func(n *kdnode) {
+		if n.left != nil {
+			walk(n.left)
+			walk(n.right)
+			return
+		}
+		cells = append(cells, n.mass.bins)
+	}
+	walk(tree.root)
+	if len(cells) != tree.leaves || len(cells) > 64 {
+		t.Errorf("Leaf count %d, expected %d and at most 64", len(cells), tree.leaves)
+	}
+
+	sum := 0.0
+	for a := range cells {
+		for _, x := range cells[a] {
+			sum += x.count
+			for b := a + 1; b < len(cells); b++ {
+				for _, y := range cells[b] {
+					disjoint := false
+					for j := 0; j < 3; j++ {
+						if x.max.Value(j) <= y.min.Value(j) || y.max.Value(j) <= x.min.Value(j) {
+							disjoint = true
+						}
+					}
+					if !disjoint {
+						t.Errorf("Bins of leaves %d and %d overlap %v %v and %v %v", a, b, x.min, x.max, y.min, y.max)
+					}
+				}
+			}
+		}
+	}
+	if !approx(sum, k.Count()) {
+		t.Errorf("Leaf counts %v != %v", sum, k.Count())
+	}
+
+	kerr, herr := 0.0, 0.0
+	for _, q := range []float64{0.1, 0.25, 0.5, 0.75, 0.9} {
+		x := e.Quantile(q)
+		kerr += math.Abs(k.CDF(x) - e.CDF(x))
+		herr += math.Abs(h.CDF(x) - e.CDF(x))
+
+		lo, hi := e.Quantile(q/2), e.Quantile(1-q/2)
+		kerr += math.Abs(k.Probability(lo, hi) - e.Probability(lo, hi))
+		herr += math.Abs(h.Probability(lo, hi) - e.Probability(lo, hi))
+
+		lower, upper := k.CDFWithBounds(x)
+		if exact := e.CDF(x); lower > exact || exact > upper {
+			t.Errorf("CDF %v at %v outside bounds [%v, %v]", exact, x, lower, upper)
+		}
+	}
+	t.Logf("CDF and Probability error: kd-tree %.4f, histogram %.4f", kerr, herr)
+	if kerr > herr {
+		t.Errorf("Error of kd-tree %v larger than histogram %v", kerr, herr)
+	}
+}
+
+func TestKDTreeSmall(t *testing.T) {
+	// While there are fewer distinct points than leaves, every leaf holds a
+	// single distinct point and queries are exact.
+	k := NewKDTree(16, 2)
+	e := NewExactHistogram(2)
+	for i := 0; i < 30; i++ {
+		v := []float64{float64(i % 5), float64(i % 3)}
+		k.Add(v)
+		e.Add(v)
+	}
+
+	for _, x := range [][]float64{{0, 0}, {2, 1}, {2.5, 1.5}, {4, 2}, {-1, 3}} {
+		if cdf := k.CDF(x); !approx(cdf, e.CDF(x)) {
+			t.Errorf("CDF at %v incorrect %v != %v", x, cdf, e.CDF(x))
+		}
+	}
+	if p := k.Probability([]float64{0, 0}, []float64{2, 2}); !approx(p, e.Probability([]float64{0, 0}, []float64{2, 2})) {
+		t.Errorf("Probability incorrect %v", p)
+	}
+
+	k.Add([]float64{1})
+	if k.Count() != 30 {
+		t.Errorf("Add of dimension 1 changed the count")
+	}
+}
+
+func TestKDTreeMerge(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	a, b, all := NewKDTree(32, 3), NewKDTree(32, 3), NewKDTree(32, 3)
+	e := NewExactHistogram(3)
+	for i := 0; i < 2000; i++ {
+		v := correlated(r)
+		if i%2 == 0 {
+			a.Add(v)
+		} else {
+			b.Add(v)
+		}
+		all.Add(v)
+		e.Add(v)
+	}
+
+	a.Merge(b)
+	if a.Count() != all.Count() || a.(*kdtree).leaves > 32 {
+		t.Errorf("Merge mismatch %v %d", a.Count(), a.(*kdtree).leaves)
+	}
+	for j := 0; j < 3; j++ {
+		if !approx(a.Mean()[j], e.Mean()[j]) || !approx(a.Variance()[j], e.Variance()[j]) {
+			t.Errorf("Moments after Merge incorrect %v %v", a.Mean(), a.Variance())
+		}
+	}
+	for _, q := range []float64{0.1, 0.5, 0.9} {
+		x := e.Quantile(q)
+		if cdf := a.CDF(x); math.Abs(cdf-e.CDF(x)) > 0.05 {
+			t.Errorf("CDF after Merge at %v incorrect %v != %v", x, cdf, e.CDF(x))
+		}
+	}
+
+	// Native histograms merge into kd-trees, clipped to their cells.
+	h := NewHistogram(16, 3)
+	h.Merge(a)
+	c := NewKDTree(32, 3)
+	c.Merge(h)
+	if !approx(c.Count(), h.Count()) || !approx(c.Mean()[0], h.Mean()[0]) || math.Abs(c.CDF(h.Mean())-h.CDF(h.Mean())) > 0.02 {
+		t.Errorf("Merge from histogram mismatch %v %v != %v %v", c.Count(), c.CDF(h.Mean()), h.Count(), h.CDF(h.Mean()))
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != a.String() || s.CDF(e.Mean()) != a.CDF(e.Mean()) {
+		t.Errorf("Leaves mismatch after Unmarshal")
+	}
+	s.Add(correlated(r))
+	if s.Count() != a.Count()+1 || s.(*kdtree).leaves > 32 {
+		t.Errorf("Add after Unmarshal incorrect %v", s.Count())
+	}
+}
@@ -0,0 +1,148 @@
+package histogram
+
+import (
+	sortpkg "sort"
+	"sync"
+)
+
+// LabeledHistogram keeps one histogram per class label, and classifies points
+// by their class-weighted densities. It is safe for concurrent use.
+type LabeledHistogram struct {
+	mu      sync.Mutex
+	classes map[string]Histogram
+	priors  map[string]float64
+	create  func() Histogram
+}
+
+// NewLabeledHistogram returns a labeled histogram, creating the histogram of
+// every class with create.
+func NewLabeledHistogram(create func() Histogram) *LabeledHistogram {
+	return &LabeledHistogram{classes: make(map[string]Histogram), create: create}
+}
+
+// Add adds values to the histogram of label.
+func (l *LabeledHistogram) Add(label string, values []float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.classes[label]
+	if !ok {
+		h = l.create()
+		l.classes[label] = h
+	}
+	h.Add(values)
+}
+
+// Labels returns the labels added so far, sorted.
+func (l *LabeledHistogram) Labels() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.labels()
+}
+
+func (l *LabeledHistogram) labels() []string {
+	r := make([]string, 0, len(l.classes))
+	for label := range l.classes {
+		r = append(r, label)
+	}
+	sortpkg.Strings(r)
+	return r
+}
+
+// Histogram returns the histogram of label, or nil if nothing was added to it.
+func (l *LabeledHistogram) Histogram(label string) Histogram {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.classes[label]
+}
+
+// CDF returns the CDF of the histogram of label at x, or -1 if nothing was
+// added to it.
+func (l *LabeledHistogram) CDF(label string, x []float64) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.classes[label]
+	if !ok {
+		return -1
+	}
+	return h.CDF(x)
+}
+
+// PDF returns the density of the histogram of label at x, or -1 if nothing
+// was added to it.
+func (l *LabeledHistogram) PDF(label string, x []float64) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.classes[label]
+	if !ok {
+		return -1
+	}
+	return PDF(h, x)
+}
+
+// SetPriors sets the prior probabilities of the labels used by Predict and
+// PredictProba. They need not sum to one, and labels without a prior get 0.
+// With nil priors, the default, every label is weighted by its count.
+func (l *LabeledHistogram) SetPriors(priors map[string]float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if priors == nil {
+		l.priors = nil
+		return
+	}
+	l.priors = make(map[string]float64, len(priors))
+	for label, p := range priors {
+		l.priors[label] = p
+	}
+}
+
+// PredictProba returns the posterior probability of every label at x, the
+// prior times the density of the label normalised to sum to one. Where no
+// label has any density, it returns the normalised priors.
+func (l *LabeledHistogram) PredictProba(x []float64) map[string]float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.predictProba(x)
+}
+
+func (l *LabeledHistogram) predictProba(x []float64) map[string]float64 {
+	priors := make(map[string]float64, len(l.classes))
+	posteriors := make(map[string]float64, len(l.classes))
+	sumPriors, sumPosteriors := 0.0, 0.0
+	for label, h := range l.classes {
+		if l.priors != nil {
+			priors[label] = l.priors[label]
+		} else {
+			priors[label] = h.Count()
+		}
+		posteriors[label] = priors[label] * max(PDF(h, x), 0)
+		sumPriors += priors[label]
+		sumPosteriors += posteriors[label]
+	}
+
+	r, sum := posteriors, sumPosteriors
+	if sumPosteriors == 0 {
+		r, sum = priors, sumPriors
+	}
+	for label := range r {
+		if sum > 0 {
+			r[label] /= sum
+		}
+	}
+	return r
+}
+
+// Predict returns the label with the largest posterior probability at x, the
+// first in sorted order on ties, or "" if nothing was added.
+func (l *LabeledHistogram) Predict(x []float64) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p := l.predictProba(x)
+
+	best := ""
+	for _, label := range l.labels() {
+		if best == "" || p[label] > p[best] {
+			best = label
+		}
+	}
+	return best
+}
@@ -0,0 +1,82 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// transaction returns a point of the normal or, with probability 0.1, the
+// fraud class, which has larger amounts at unusual hours.
+func transaction(r *rand.Rand) (string, []float64) {
+	if r.Float64() < 0.1 {
+		return "fraud", []float64{math.Exp(r.NormFloat64()*0.5 + 5), r.NormFloat64()*1.5 + 3}
+	}
+	return "normal", []float64{math.Exp(r.NormFloat64()*0.7 + 3), r.NormFloat64()*3 + 14}
+}
+
+func TestLabeledHistogram(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	l := NewLabeledHistogram(func() Histogram { return NewHistogram(32, 2) })
+	for i := 0; i < 5000; i++ {
+		l.Add(transaction(r))
+	}
+
+	if labels := l.Labels(); len(labels) != 2 || labels[0] != "fraud" || labels[1] != "normal" {
+		t.Errorf("Labels %v", labels)
+	}
+	if l.Histogram("other") != nil || l.CDF("other", []float64{0, 0}) != -1 || l.PDF("other", []float64{0, 0}) != -1 {
+		t.Errorf("Unknown label has a histogram")
+	}
+	if c := l.CDF("normal", []float64{math.Inf(1), 14}); math.Abs(c-0.5) > 0.05 {
+		t.Errorf("CDF of normal hours at 14 %v != 0.5", c)
+	}
+
+	correct, total := map[string]int{}, map[string]int{}
+	for i := 0; i < 2000; i++ {
+		label, x := transaction(r)
+		total[label]++
+		if l.Predict(x) == label {
+			correct[label]++
+		}
+		p := l.PredictProba(x)
+		if !approx(p["normal"]+p["fraud"], 1) {
+			t.Errorf("Probabilities %v do not sum to 1", p)
+		}
+	}
+	for label := range total {
+		a := float64(correct[label]) / float64(total[label])
+		t.Logf("Accuracy of %s: %v", label, a)
+		if a < 0.75 {
+			t.Errorf("Accuracy of %s %v", label, a)
+		}
+	}
+
+	// Posteriors weight densities by the priors.
+	l.SetPriors(map[string]float64{"normal": 3, "fraud": 1})
+	checked := 0
+	for i := 0; i < 1000; i++ {
+		_, x := transaction(r)
+		pf, pn := l.PDF("fraud", x), l.PDF("normal", x)
+		if pf > 0 && pn > 0 {
+			if p := l.PredictProba(x); !approx(p["fraud"], pf/(pf+3*pn)) {
+				t.Errorf("Probability at %v %v != %v", x, p["fraud"], pf/(pf+3*pn))
+			}
+			checked++
+		}
+	}
+	if checked == 0 {
+		t.Errorf("No point with density of both classes")
+	}
+
+	if p := l.PredictProba([]float64{-100, -100}); !approx(p["normal"], 0.75) {
+		t.Errorf("Probability without density %v != priors", p)
+	}
+	l.SetPriors(nil)
+	if p := l.Predict([]float64{-100, -100}); p != "normal" {
+		t.Errorf("Prediction without density %v != normal", p)
+	}
+	if p := NewLabeledHistogram(func() Histogram { return NewHistogram(32, 2) }).Predict([]float64{0, 0}); p != "" {
+		t.Errorf("Prediction without labels %q", p)
+	}
+}
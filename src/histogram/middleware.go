@@ -0,0 +1,105 @@
+package histogram
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Middleware records every request served by next as a point of a 3-D series
+// of r, named name: the latency in seconds, the request body size and the
+// response body size in bytes. Joint questions such as the fraction of slow,
+// large responses can then be answered with Probability.
+//
+// Series are labelled with the route, the method and the status class such as
+// "2xx". route returns the route of a served request. If nil, requests are
+// labelled with the ServeMux pattern they matched, req.Pattern, or "other"
+// if there is none, such as for unmatched requests or other routers, so that
+// the number of series stays bounded. A route must likewise map paths holding
+// identifiers such as "/items/42" to a fixed set of routes. r must have
+// dimension 3.
+func Middleware(r *Registry, name string, route func(req *http.Request) string, next http.Handler) http.Handler {
+	if r.dimension != 3 {
+		panic("histogram: Middleware requires a registry of dimension 3")
+	}
+	if route == nil {
+		route = func(req *http.Request) string {
+			if req.Pattern == "" {
+				return "other"
+			}
+			return req.Pattern
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		body := &countingReader{ReadCloser: req.Body}
+		if req.Body != nil {
+			req.Body = body
+		}
+		rw := &recordingWriter{ResponseWriter: w}
+
+		next.ServeHTTP(rw, req)
+
+		status := rw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		received := body.n
+		if req.ContentLength > received {
+			received = req.ContentLength
+		}
+
+		r.Get(name, Labels{
+			"route":  route(req),
+			"method": req.Method,
+			"status": strconv.Itoa(status/100) + "xx",
+		}).Add([]float64{time.Since(start).Seconds(), float64(received), float64(rw.written)})
+	})
+}
+
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type recordingWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush supports streaming handlers.
+func (w *recordingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
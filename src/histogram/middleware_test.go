@@ -0,0 +1,133 @@
+package histogram
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	r := NewRegistry(32, 3)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, req *http.Request) {
+		io.Copy(io.Discard, req.Body)
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/items/", func(w http.ResponseWriter, req *http.Request) {
+		if strings.TrimPrefix(req.URL.Path, "/items/") == "large" {
+			w.Write(make([]byte, 1<<20))
+			return
+		}
+		w.Write([]byte("small"))
+	})
+	route := func(req *http.Request) string {
+		if strings.HasPrefix(req.URL.Path, "/items/") {
+			return "/items/{id}"
+		}
+		return req.URL.Path
+	}
+	server := httptest.NewServer(Middleware(r, "http_requests", route, mux))
+	defer server.Close()
+
+	for i := 0; i < 10; i++ {
+		id := "small"
+		if i%5 == 0 {
+			id = "large"
+		}
+		resp, err := http.Get(server.URL + "/items/" + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	resp, err := http.Post(server.URL+"/upload", "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	resp, err = http.Get(server.URL + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	inf := math.Inf(1)
+	s := r.Lookup("http_requests", Labels{"route": "/items/{id}", "method": "GET", "status": "2xx"})
+	if s == nil {
+		for _, s := range r.All() {
+			t.Logf("%s %v", s.Name, s.Labels)
+		}
+		t.Fatalf("Missing series")
+	}
+	s.Do(func(h Histogram) {
+		if h.Count() != 10 {
+			t.Errorf("Count mismatch %v != 10", h.Count())
+		}
+		if max := h.Max(); max[2] != 1<<20 {
+			t.Errorf("Max response bytes %v != %v", max[2], 1<<20)
+		}
+		// Two of ten responses are larger than 1kB, however fast.
+		if p := h.Probability([]float64{-inf, -inf, 1 << 10}, []float64{inf, inf, inf}); !approx(p, 0.2) {
+			t.Errorf("Probability of large responses %v != 0.2", p)
+		}
+	})
+
+	s = r.Lookup("http_requests", Labels{"route": "/upload", "method": "POST", "status": "2xx"})
+	if s == nil {
+		t.Fatalf("Missing upload series")
+	}
+	s.Do(func(h Histogram) {
+		if mean := h.Mean(); mean[1] != 7 || mean[2] != 0 {
+			t.Errorf("Unexpected request and response bytes %v", mean)
+		}
+	})
+
+	if r.Lookup("http_requests", Labels{"route": "/missing", "method": "GET", "status": "4xx"}) == nil {
+		t.Errorf("Missing series of unmatched requests")
+	}
+}
+
+func TestMiddlewareDefaultRoute(t *testing.T) {
+	// Without a route, series are labelled with the matched pattern, and
+	// requests without one share a single series. The router sets the pattern
+	// as ServeMux does.
+	r := NewRegistry(32, 3)
+	router := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, "/items/") {
+			http.NotFound(w, req)
+			return
+		}
+		req.Pattern = "GET /items/{id}"
+		w.Write([]byte("ok"))
+	})
+	handler := Middleware(r, "http_requests", nil, router)
+	for _, path := range []string{"/items/1", "/items/2", "/items/3", "/missing", "/admin.php"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	for _, test := range []struct {
+		route, status string
+		count         float64
+	}{
+		{"GET /items/{id}", "2xx", 3},
+		{"other", "4xx", 2},
+	} {
+		s := r.Lookup("http_requests", Labels{"route": test.route, "method": "GET", "status": test.status})
+		if s == nil {
+			t.Fatalf("Missing series of %s", test.route)
+		}
+		s.Do(func(h Histogram) {
+			if h.Count() != test.count {
+				t.Errorf("Count of %s %v != %v", test.route, h.Count(), test.count)
+			}
+		})
+	}
+	if n := len(r.All()); n != 2 {
+		t.Errorf("%d series, expected 2", n)
+	}
+}
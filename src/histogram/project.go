@@ -0,0 +1,42 @@
+package histogram
+
+// Project returns the marginal histogram of h in dims, in that order, such
+// as the 2-D joint of two dimensions of a 5-D histogram. Every bin keeps its
+// count, and the mean, variance, min and max of dims. The result is exact for
+// exact histograms and otherwise a native histogram with as many bins as h,
+// which merges bins as more are added. Project returns nil if dims is empty,
+// repeats a dimension or is out of range.
+func Project(h Histogram, dims []int) Histogram {
+	d := h.Dimension()
+	b, ok := h.(boxer)
+	if !ok || len(dims) == 0 {
+		return nil
+	}
+	seen := make(map[int]bool, len(dims))
+	for _, j := range dims {
+		if j < 0 || j >= d || seen[j] {
+			return nil
+		}
+		seen[j] = true
+	}
+
+	if e, ok := h.(*exactHistogram); ok {
+		r := NewExactHistogram(len(dims))
+		x := make([]float64, len(dims))
+		for _, p := range e.points {
+			for i, j := range dims {
+				x[i] = p[j]
+			}
+			r.Add(x)
+		}
+		return r
+	}
+
+	bins := nonempty(b.boxes())
+	r := NewHistogram(capacity(h, len(bins)), len(dims)).(*histogram)
+	for i := range bins {
+		r.insert(bins[i].project(dims))
+		r.total += bins[i].count
+	}
+	return r
+}
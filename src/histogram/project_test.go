@@ -0,0 +1,91 @@
+package histogram
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h, e := NewHistogram(64, 3), NewExactHistogram(3)
+	for i := 0; i < 2000; i++ {
+		x := r.NormFloat64()
+		v := []float64{x, r.ExpFloat64(), 2*x + r.NormFloat64()}
+		h.Add(v)
+		e.Add(v)
+	}
+
+	dims := []int{2, 0}
+	p, pe := Project(h, dims), Project(e, dims)
+	if p.Dimension() != 2 || pe.Dimension() != 2 || p.Count() != h.Count() || pe.Count() != e.Count() {
+		t.Fatalf("Projection of dimension %d, %d and count %v, %v", p.Dimension(), pe.Dimension(), p.Count(), pe.Count())
+	}
+	for i, j := range dims {
+		if !approx(p.Mean()[i], h.Mean()[j]) || !approx(p.Variance()[i], h.Variance()[j]) {
+			t.Errorf("Moments of projected dimension %d mismatch %v %v != %v %v", j, p.Mean(), p.Variance(), h.Mean(), h.Variance())
+		}
+		if p.Min()[i] != h.Min()[j] || p.Max()[i] != h.Max()[j] || pe.Min()[i] != e.Min()[j] {
+			t.Errorf("Range of projected dimension %d mismatch", j)
+		}
+	}
+
+	// Queries of the projection match those of h unbounded in the dropped
+	// dimension.
+	inf := math.Inf(1)
+	for _, x := range [][]float64{{0, 0}, {-1, 0.5}, {2, 1}} {
+		lo, hi := []float64{-1, -inf, x[0]}, []float64{x[1], inf, x[0] + 2}
+		if a, b := p.Probability([]float64{x[0], -1}, []float64{x[0] + 2, x[1]}), h.Probability(lo, hi); !approx(a, b) {
+			t.Errorf("Probability of projection %v != %v", a, b)
+		}
+		if a, b := pe.CDF(x), e.CDF([]float64{x[1], inf, x[0]}); a != b {
+			t.Errorf("CDF of exact projection %v != %v", a, b)
+		}
+	}
+
+	// The projection is a full histogram.
+	other := NewHistogram(64, 2)
+	other.Add([]float64{1, 1})
+	other.Merge(p)
+	if other.Count() != h.Count()+1 {
+		t.Errorf("Count after merging projection %v", other.Count())
+	}
+	p.Add([]float64{0, 0})
+	if p.Count() != h.Count()+1 || len(p.(*histogram).bins) > 64 {
+		t.Errorf("Projection after Add has count %v and %d bins", p.Count(), len(p.(*histogram).bins))
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.String() != p.String() {
+		t.Errorf("Projection mismatch after Unmarshal")
+	}
+
+	for _, dims := range [][]int{{}, {3}, {-1}, {1, 1}} {
+		if p := Project(h, dims); p != nil {
+			t.Errorf("Projection onto %v %v", dims, p)
+		}
+	}
+}
+
+func TestProjectKDTree(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	h := NewKDTree(32, 3)
+	for i := 0; i < 2000; i++ {
+		h.Add([]float64{r.NormFloat64(), r.NormFloat64(), r.Float64()})
+	}
+
+	p := Project(h, []int{1})
+	if p.Dimension() != 1 || !approx(p.Count(), h.Count()) || !approx(p.Mean()[0], h.Mean()[1]) {
+		t.Errorf("Projection of k-d tree has dimension %d, count %v and mean %v", p.Dimension(), p.Count(), p.Mean())
+	}
+	if q := MarginalQuantile(p, 0.5); math.Abs(q[0]) > 0.1 {
+		t.Errorf("Median of projection %v", q)
+	}
+}
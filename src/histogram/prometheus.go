@@ -0,0 +1,155 @@
+package histogram
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	sortpkg "sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusOptions describes a metric written in the Prometheus text
+// exposition format by WritePrometheusHistogram and WritePrometheusSummary.
+type PrometheusOptions struct {
+	// Name is the metric name, Help its optional description.
+	Name string
+	Help string
+	// Labels are attached to every sample.
+	Labels map[string]string
+	// Dimension selects the marginal of a multidimensional histogram.
+	Dimension int
+	// Buckets are the upper bounds of histogram buckets. +Inf is implied.
+	Buckets []float64
+	// Quantiles are the quantiles of a summary.
+	Quantiles []float64
+}
+
+var prometheusName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+var prometheusLabel = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// WritePrometheusHistogram writes h as a Prometheus histogram with cumulative
+// bucket counts estimated by the CDF at every bucket boundary.
+func WritePrometheusHistogram(w io.Writer, h Histogram, o PrometheusOptions) error {
+	labels, err := o.validate(h, "le")
+	if err != nil {
+		return err
+	}
+	for i := range o.Buckets {
+		if math.IsNaN(o.Buckets[i]) || (i > 0 && o.Buckets[i] <= o.Buckets[i-1]) {
+			return fmt.Errorf("histogram: buckets %v not strictly increasing", o.Buckets)
+		}
+	}
+
+	count := h.Count()
+	bw := bufio.NewWriter(w)
+	o.writeHeader(bw, "histogram")
+	for _, le := range o.Buckets {
+		if math.IsInf(le, 1) {
+			break
+		}
+		cumulative := 0.0
+		if count > 0 {
+			cumulative = marginal(h, []int{o.Dimension}, []float64{le}) * count
+		}
+		writeSample(bw, o.Name+"_bucket", labels, "le", le, cumulative)
+	}
+	writeSample(bw, o.Name+"_bucket", labels, "le", math.Inf(1), count)
+	o.writeSumCount(bw, h, labels)
+	return bw.Flush()
+}
+
+// WritePrometheusSummary writes h as a Prometheus summary with the requested
+// quantiles of the selected marginal.
+func WritePrometheusSummary(w io.Writer, h Histogram, o PrometheusOptions) error {
+	labels, err := o.validate(h, "quantile")
+	if err != nil {
+		return err
+	}
+	for _, q := range o.Quantiles {
+		if !(q >= 0 && q <= 1) {
+			return fmt.Errorf("histogram: quantile %v out of range", q)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	o.writeHeader(bw, "summary")
+	for _, q := range o.Quantiles {
+		value := math.NaN()
+		if h.Count() > 0 {
+			value = MarginalQuantile(h, q)[o.Dimension]
+		}
+		writeSample(bw, o.Name, labels, "quantile", q, value)
+	}
+	o.writeSumCount(bw, h, labels)
+	return bw.Flush()
+}
+
+// validate checks the metric and label names and returns the formatted labels,
+// sorted by name. reserved is the label name used by the metric type.
+func (o PrometheusOptions) validate(h Histogram, reserved string) ([]string, error) {
+	if !prometheusName.MatchString(o.Name) {
+		return nil, fmt.Errorf("histogram: invalid metric name %q", o.Name)
+	}
+	if o.Dimension < 0 || o.Dimension >= h.Dimension() {
+		return nil, fmt.Errorf("histogram: dimension %d out of range", o.Dimension)
+	}
+
+	var labels []string
+	for name, value := range o.Labels {
+		if !prometheusLabel.MatchString(name) || strings.HasPrefix(name, "__") || name == reserved {
+			return nil, fmt.Errorf("histogram: invalid label name %q", name)
+		}
+		labels = append(labels, name+`="`+escapeLabel(value)+`"`)
+	}
+	sortpkg.Strings(labels)
+	return labels, nil
+}
+
+func (o PrometheusOptions) writeHeader(w io.Writer, kind string) {
+	if o.Help != "" {
+		help := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(o.Help)
+		fmt.Fprintf(w, "# HELP %s %s\n", o.Name, help)
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", o.Name, kind)
+}
+
+func (o PrometheusOptions) writeSumCount(w io.Writer, h Histogram, labels []string) {
+	count := h.Count()
+	sum := 0.0
+	if count > 0 {
+		sum = h.Mean()[o.Dimension] * count
+	}
+	writeSample(w, o.Name+"_sum", labels, "", 0, sum)
+	writeSample(w, o.Name+"_count", labels, "", 0, count)
+}
+
+// writeSample writes a single sample, adding the label name=bound if name is
+// not empty.
+func writeSample(w io.Writer, metric string, labels []string, name string, bound, value float64) {
+	if name != "" {
+		labels = append(labels[:len(labels):len(labels)], name+`="`+formatPrometheus(bound)+`"`)
+	}
+	if len(labels) > 0 {
+		metric += "{" + strings.Join(labels, ",") + "}"
+	}
+	fmt.Fprintf(w, "%s %s\n", metric, formatPrometheus(value))
+}
+
+func formatPrometheus(x float64) string {
+	switch {
+	case math.IsInf(x, 1):
+		return "+Inf"
+	case math.IsInf(x, -1):
+		return "-Inf"
+	case math.IsNaN(x):
+		return "NaN"
+	}
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
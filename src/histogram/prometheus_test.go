@@ -0,0 +1,110 @@
+package histogram
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestPrometheus(t *testing.T) {
+	h1 := NewHistogram(8, 1)
+	h2 := NewHistogram(8, 2)
+	for i := 0; i < 100; i++ {
+		v := float64(i%10) / 10
+		h1.Add([]float64{v})
+		h2.Add([]float64{float64(i), v})
+	}
+
+	for _, test := range []struct {
+		golden string
+		write  func(b *bytes.Buffer) error
+	}{
+		{"prometheus_histogram.golden", func(b *bytes.Buffer) error {
+			return WritePrometheusHistogram(b, h1, PrometheusOptions{
+				Name:    "request_duration_seconds",
+				Help:    "Request latency.\nEstimated from a streaming histogram.",
+				Labels:  map[string]string{"route": "/api", "method": "GET"},
+				Buckets: []float64{0.1, 0.25, 0.5, 1, math.Inf(1)},
+			})
+		}},
+		{"prometheus_summary.golden", func(b *bytes.Buffer) error {
+			return WritePrometheusSummary(b, h1, PrometheusOptions{
+				Name:      "request_duration_seconds",
+				Labels:    map[string]string{"path": `C:\tmp "quoted"`},
+				Quantiles: []float64{0, 0.5, 0.9, 0.99, 1},
+			})
+		}},
+		{"prometheus_marginal.golden", func(b *bytes.Buffer) error {
+			if err := WritePrometheusHistogram(b, h2, PrometheusOptions{
+				Name:      "response_size_bytes",
+				Dimension: 1,
+				Buckets:   []float64{0.2, 0.4, 0.8},
+			}); err != nil {
+				return err
+			}
+			return WritePrometheusSummary(b, h2, PrometheusOptions{
+				Name:      "response_size_bytes_summary",
+				Dimension: 1,
+				Quantiles: []float64{0.5},
+			})
+		}},
+		{"prometheus_empty.golden", func(b *bytes.Buffer) error {
+			empty := NewHistogram(8, 1)
+			if err := WritePrometheusHistogram(b, empty, PrometheusOptions{Name: "empty", Buckets: []float64{1}}); err != nil {
+				return err
+			}
+			return WritePrometheusSummary(b, empty, PrometheusOptions{Name: "empty_summary", Quantiles: []float64{0.5}})
+		}},
+	} {
+		var b bytes.Buffer
+		if err := test.write(&b); err != nil {
+			t.Fatalf("%s: %v", test.golden, err)
+		}
+
+		path := filepath.Join("testdata", test.golden)
+		if *update {
+			if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b.Bytes(), want) {
+			t.Errorf("%s mismatch\ngot:\n%s\nwant:\n%s", test.golden, b.Bytes(), want)
+		}
+	}
+}
+
+func TestPrometheusErrors(t *testing.T) {
+	h := NewHistogram(8, 1)
+	h.Add([]float64{1})
+
+	for _, o := range []PrometheusOptions{
+		{Name: "1invalid"},
+		{Name: "valid", Labels: map[string]string{"le": "1"}},
+		{Name: "valid", Labels: map[string]string{"__name": "1"}},
+		{Name: "valid", Dimension: 1},
+		{Name: "valid", Buckets: []float64{2, 1}},
+	} {
+		if err := WritePrometheusHistogram(io.Discard, h, o); err == nil {
+			t.Errorf("Expected histogram error for %+v", o)
+		}
+	}
+
+	for _, o := range []PrometheusOptions{
+		{Name: "valid", Labels: map[string]string{"quantile": "1"}},
+		{Name: "valid", Quantiles: []float64{1.5}},
+	} {
+		if err := WritePrometheusSummary(io.Discard, h, o); err == nil {
+			t.Errorf("Expected summary error for %+v", o)
+		}
+	}
+}
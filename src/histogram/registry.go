@@ -0,0 +1,171 @@
+package histogram
+
+import (
+	sortpkg "sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Labels distinguish series of the same name in a Registry.
+type Labels map[string]string
+
+// Registry holds histograms by name and label set, all created with the same
+// bin count and dimension. It is safe for concurrent use.
+type Registry struct {
+	mu        sync.Mutex
+	bins      int
+	dimension int
+	series    map[string]*Series
+	now       func() time.Time
+}
+
+// Series is a histogram held by a Registry. Its methods are safe for
+// concurrent use.
+type Series struct {
+	Name   string
+	Labels Labels
+
+	mu      sync.Mutex
+	h       Histogram
+	updated time.Time
+	now     func() time.Time
+}
+
+// NewRegistry returns a registry creating histograms of at most n bins and
+// dimension d.
+func NewRegistry(n, d int) *Registry {
+	return &Registry{
+		bins:      n,
+		dimension: d,
+		series:    make(map[string]*Series),
+		now:       time.Now,
+	}
+}
+
+// Get returns the series of the given name and labels, creating it if needed.
+func (r *Registry) Get(name string, labels Labels) *Series {
+	key := seriesKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.series[key]
+	if !ok {
+		copied := make(Labels, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		s = &Series{
+			Name:    name,
+			Labels:  copied,
+			h:       NewHistogram(r.bins, r.dimension),
+			updated: r.now(),
+			now:     r.now,
+		}
+		r.series[key] = s
+	}
+	return s
+}
+
+// Lookup returns the series of the given name and labels, or nil if it does
+// not exist.
+func (r *Registry) Lookup(name string, labels Labels) *Series {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.series[seriesKey(name, labels)]
+}
+
+// All returns all series, sorted by name and labels.
+func (r *Registry) All() []*Series {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := make([]*Series, 0, len(r.series))
+	for _, s := range r.series {
+		all = append(all, s)
+	}
+	sortpkg.Slice(all, func(i, j int) bool {
+		if all[i].Name != all[j].Name {
+			return all[i].Name < all[j].Name
+		}
+		return formatLabels(all[i].Labels) < formatLabels(all[j].Labels)
+	})
+	return all
+}
+
+// Delete removes the series of the given name and labels, reporting whether
+// it existed.
+func (r *Registry) Delete(name string, labels Labels) bool {
+	key := seriesKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.series[key]
+	delete(r.series, key)
+	return ok
+}
+
+// Expire removes all series without values added for longer than idle and
+// returns the number of series removed.
+func (r *Registry) Expire(idle time.Duration) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := r.now().Add(-idle)
+	n := 0
+	for key, s := range r.series {
+		s.mu.Lock()
+		expired := s.updated.Before(cutoff)
+		s.mu.Unlock()
+
+		if expired {
+			delete(r.series, key)
+			n++
+		}
+	}
+	return n
+}
+
+// seriesKey identifies a series by its name and labels.
+func seriesKey(name string, labels Labels) string {
+	return name + "{" + formatLabels(labels) + "}"
+}
+
+// formatLabels formats labels sorted by name, quoting their values.
+func formatLabels(labels Labels) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sortpkg.Strings(names)
+
+	var b strings.Builder
+	for i, k := range names {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(strconv.Quote(labels[k]))
+	}
+	return b.String()
+}
+
+// Add adds values to the histogram of the series.
+func (s *Series) Add(values []float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.h.Add(values)
+	s.updated = s.now()
+}
+
+// Do calls f with the histogram of the series, holding its lock. f must not
+// retain the histogram.
+func (s *Series) Do(f func(h Histogram)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.h)
+}
@@ -0,0 +1,58 @@
+package histogram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(8, 2)
+	now := time.Unix(0, 0)
+	r.now = func() time.Time { return now }
+
+	labels := Labels{"route": "/api", "method": "GET"}
+	s := r.Get("latency", labels)
+	if r.Get("latency", Labels{"method": "GET", "route": "/api"}) != s {
+		t.Errorf("Expected the same series for equal labels")
+	}
+	if r.Get("latency", Labels{"route": "/api"}) == s || r.Get("latency", nil) == s {
+		t.Errorf("Expected different series for different labels")
+	}
+
+	// The series keeps its own copy of the labels.
+	labels["route"] = "/other"
+	if s.Labels["route"] != "/api" || r.Lookup("latency", labels) != nil {
+		t.Errorf("Series labels changed with the caller's map")
+	}
+
+	s.Add([]float64{1, 2})
+	s.Add([]float64{3, 4})
+	s.Do(func(h Histogram) {
+		if h.Count() != 2 || h.Dimension() != 2 {
+			t.Errorf("Unexpected histogram count %v dimension %d", h.Count(), h.Dimension())
+		}
+	})
+
+	all := r.All()
+	if len(all) != 3 || all[0].Name != "latency" || len(all[0].Labels) != 0 || all[1] != s {
+		t.Errorf("Unexpected series order %v", all)
+	}
+
+	if !r.Delete("latency", Labels{"route": "/api"}) || r.Delete("latency", Labels{"route": "/api"}) {
+		t.Errorf("Unexpected Delete result")
+	}
+	if len(r.All()) != 2 {
+		t.Errorf("Expected 2 series after Delete, got %d", len(r.All()))
+	}
+
+	// Only series updated within the idle period survive.
+	now = now.Add(time.Minute)
+	s.Add([]float64{5, 6})
+	now = now.Add(30 * time.Second)
+	if n := r.Expire(45 * time.Second); n != 1 {
+		t.Errorf("Expected 1 expired series, got %d", n)
+	}
+	if all := r.All(); len(all) != 1 || all[0] != s {
+		t.Errorf("Unexpected series after Expire %v", all)
+	}
+}
@@ -0,0 +1,184 @@
+package histogram
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	eighths = []rune(" ▏▎▍▌▋▊▉█")
+	shades  = []rune(" ░▒▓█")
+)
+
+// Bars renders the marginal density of dimension dim as a horizontal bar chart
+// of rows equal width intervals between Min and Max. The longest bar is width
+// characters wide.
+func Bars(h Histogram, dim, rows, width int) string {
+	if h.Count() == 0 || dim < 0 || dim >= h.Dimension() || rows < 1 || width < 1 {
+		return ""
+	}
+
+	edges, mass := intervals(h, dim, rows)
+	rows = len(mass)
+	peak := 0.0
+	for i := range mass {
+		peak = math.Max(peak, mass[i])
+	}
+
+	labels := make([]string, rows)
+	labelWidth := 0
+	for i := range labels {
+		labels[i] = formatLabel(edges[i]) + " .. " + formatLabel(edges[i+1])
+		if n := len(labels[i]); n > labelWidth {
+			labelWidth = n
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "dimension %d, count %v\n", dim, h.Count())
+	for i := range mass {
+		fmt.Fprintf(&b, "%*s │%s %.4f\n", labelWidth, labels[i], bar(mass[i]/peak*float64(width), width), mass[i])
+	}
+	return b.String()
+}
+
+// Heatmap renders the joint density of dimensions x and y as a grid of rows by
+// cols shaded cells, with y increasing upwards.
+func Heatmap(h Histogram, x, y, rows, cols int) string {
+	d := h.Dimension()
+	if h.Count() == 0 || x < 0 || x >= d || y < 0 || y >= d || x == y || rows < 1 || cols < 1 {
+		return ""
+	}
+
+	xedges, yedges, mass := grid(h, x, y, rows, cols)
+	peak := 0.0
+	for r := range mass {
+		for c := range mass[r] {
+			peak = math.Max(peak, mass[r][c])
+		}
+	}
+
+	labels := make([]string, rows+1)
+	labelWidth := 0
+	for r := range labels {
+		labels[r] = formatLabel(yedges[r])
+		if n := len(labels[r]); n > labelWidth {
+			labelWidth = n
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "dimensions %d x %d, count %v\n", x, y, h.Count())
+	for r := rows - 1; r >= 0; r-- {
+		fmt.Fprintf(&b, "%*s │", labelWidth, labels[r+1])
+		for c := range mass[r] {
+			b.WriteRune(shade(mass[r][c] / peak))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "%*s └%s\n", labelWidth, labels[0], strings.Repeat("─", cols))
+
+	left, right := formatLabel(xedges[0]), formatLabel(xedges[cols])
+	gap := cols - len(left) - len(right)
+	if gap < 1 {
+		gap = 1
+	}
+	fmt.Fprintf(&b, "%*s  %s%s%s\n", labelWidth, "", left, strings.Repeat(" ", gap), right)
+	fmt.Fprintf(&b, "%*s  %s max cell %.4f\n", labelWidth, "", string(shades[1:]), peak)
+	return b.String()
+}
+
+// intervals splits the range of dimension dim into rows equal width intervals
+// and returns their edges and the fraction of points within each.
+func intervals(h Histogram, dim, rows int) (edges, mass []float64) {
+	lo, hi := h.Min()[dim], h.Max()[dim]
+	if lo == hi {
+		rows = 1
+	}
+	edges = linspace(lo, hi, rows+1)
+
+	mass = make([]float64, rows)
+	prev := 0.0
+	for i := range mass {
+		cdf := marginal(h, []int{dim}, []float64{edges[i+1]})
+		mass[i] = math.Max(cdf-prev, 0)
+		prev = cdf
+	}
+	return
+}
+
+// grid splits the ranges of dimensions x and y into cols by rows cells and
+// returns their edges and the fraction of points within each cell, indexed
+// by row and column.
+func grid(h Histogram, x, y, rows, cols int) (xedges, yedges []float64, mass [][]float64) {
+	lo, hi := h.Min(), h.Max()
+	xedges = linspace(lo[x], hi[x], cols+1)
+	yedges = linspace(lo[y], hi[y], rows+1)
+
+	// cdf[r][c] is the joint CDF at the upper right corner of cell (r-1, c-1).
+	cdf := make([][]float64, rows+1)
+	for r := range cdf {
+		cdf[r] = make([]float64, cols+1)
+		if r == 0 {
+			continue
+		}
+		for c := 1; c <= cols; c++ {
+			cdf[r][c] = marginal(h, []int{x, y}, []float64{xedges[c], yedges[r]})
+		}
+	}
+
+	mass = make([][]float64, rows)
+	for r := range mass {
+		mass[r] = make([]float64, cols)
+		for c := range mass[r] {
+			mass[r][c] = math.Max(cdf[r+1][c+1]-cdf[r][c+1]-cdf[r+1][c]+cdf[r][c], 0)
+		}
+	}
+	return
+}
+
+// marginal returns the joint CDF of the given dimensions at x, leaving all
+// other dimensions unbounded.
+func marginal(h Histogram, dims []int, x []float64) float64 {
+	point := make([]float64, h.Dimension())
+	for i := range point {
+		point[i] = math.Inf(1)
+	}
+	for i, dim := range dims {
+		point[dim] = x[i]
+	}
+	return h.CDF(point)
+}
+
+// bar draws a bar of length v characters, padded to width.
+func bar(v float64, width int) string {
+	full := int(v)
+	if full > width {
+		full = width
+	}
+	r := strings.Repeat(string(eighths[8]), full)
+	if full < width {
+		r += string(eighths[int((v-float64(full))*8)])
+		r += strings.Repeat(" ", width-full-1)
+	}
+	return r
+}
+
+// shade maps a fraction of the densest cell to a shading character. Any
+// non-zero mass is visible.
+func shade(f float64) rune {
+	if !(f > 0) {
+		return shades[0]
+	}
+	i := int(math.Ceil(f * float64(len(shades)-1)))
+	if i >= len(shades) {
+		i = len(shades) - 1
+	}
+	return shades[i]
+}
+
+func formatLabel(x float64) string {
+	return strconv.FormatFloat(x, 'g', 4, 64)
+}
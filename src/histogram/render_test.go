@@ -0,0 +1,79 @@
+package histogram
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBars(t *testing.T) {
+	h := NewHistogram(32, 2)
+	for i := 0; i < 1000; i++ {
+		h.Add([]float64{rand.NormFloat64(), rand.Float64()})
+	}
+
+	str := Bars(h, 0, 10, 40)
+	lines := strings.Split(strings.TrimSuffix(str, "\n"), "\n")
+	if len(lines) != 11 {
+		t.Fatalf("Expected header and 10 rows, got %d lines\n%s", len(lines), str)
+	}
+
+	// The bars of a normal distribution are longest in the middle.
+	var lengths []int
+	for _, line := range lines[1:] {
+		lengths = append(lengths, strings.Count(line, "█"))
+	}
+	if lengths[0] >= lengths[5] || lengths[9] >= lengths[5] {
+		t.Errorf("Unexpected bar lengths %v\n%s", lengths, str)
+	}
+	for _, n := range lengths {
+		if n > 40 {
+			t.Errorf("Bar longer than width %v\n%s", lengths, str)
+		}
+	}
+
+	for _, str := range []string{Bars(h, 2, 10, 40), Bars(NewHistogram(32, 2), 0, 10, 40), Bars(h, 0, 0, 40)} {
+		if str != "" {
+			t.Errorf("Expected empty rendering, got\n%s", str)
+		}
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	h := NewHistogram(64, 3)
+	for i := 0; i < 1000; i++ {
+		v := rand.Float64()
+		h.Add([]float64{v, v, rand.Float64()})
+	}
+
+	str := Heatmap(h, 0, 1, 10, 10)
+	lines := strings.Split(strings.TrimSuffix(str, "\n"), "\n")
+	if len(lines) != 14 {
+		t.Fatalf("Expected 14 lines, got %d\n%s", len(lines), str)
+	}
+
+	// Perfectly correlated dimensions are densest on the diagonal.
+	for r, line := range lines[1:11] {
+		cells := []rune(line[strings.Index(line, "│")+len("│"):])
+		if len(cells) != 10 {
+			t.Fatalf("Expected 10 cells, got %d\n%s", len(cells), str)
+		}
+		diagonal := strings.IndexRune(string(shades), cells[9-r])
+		if diagonal < 1 {
+			t.Errorf("Expected shaded diagonal cell in row %d\n%s", r, str)
+		}
+		for c := range cells {
+			if strings.IndexRune(string(shades), cells[c]) > diagonal {
+				t.Errorf("Cell %d in row %d denser than the diagonal\n%s", c, r, str)
+			}
+		}
+	}
+	if !utf8.ValidString(str) {
+		t.Errorf("Invalid UTF-8 rendering")
+	}
+
+	if str := Heatmap(h, 0, 0, 10, 10); str != "" {
+		t.Errorf("Expected empty rendering, got\n%s", str)
+	}
+}
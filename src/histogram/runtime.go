@@ -0,0 +1,183 @@
+package histogram
+
+import (
+	"fmt"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// NewHistogramFromRuntime converts a runtime/metrics histogram into a 1-D
+// histogram of at most n bins. The unbounded outer buckets of runtime
+// histograms are collapsed onto their finite boundary.
+func NewHistogramFromRuntime(n int, h *metrics.Float64Histogram) (Histogram, error) {
+	counts := make([]float64, len(h.Counts))
+	for i, c := range h.Counts {
+		counts[i] = float64(c)
+	}
+	return NewHistogramFromBuckets(n, h.Buckets, counts)
+}
+
+// RuntimeCollector periodically samples runtime/metrics histograms, such as
+// /sched/pauses/total/gc:seconds, into windowed histograms. It is safe for
+// concurrent use.
+type RuntimeCollector struct {
+	mu      sync.Mutex
+	bins    int
+	samples []metrics.Sample
+	last    []*metrics.Float64Histogram
+	windows map[string]*Window
+	err     error
+	stop    chan struct{}
+	done    chan struct{}
+
+	// read returns the current value of every sample, nil for samples that
+	// are not histograms. It is replaced by tests.
+	read func() []*metrics.Float64Histogram
+}
+
+// NewRuntimeCollector returns a collector of the named runtime metrics, each
+// kept in a window of the last periods collections using histograms of at
+// most n bins. All names must be runtime metrics of histogram kind.
+func NewRuntimeCollector(n, periods int, names ...string) (*RuntimeCollector, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("histogram: invalid bin count %d", n)
+	}
+	kinds := make(map[string]metrics.ValueKind)
+	for _, d := range metrics.All() {
+		kinds[d.Name] = d.Kind
+	}
+
+	c := &RuntimeCollector{
+		bins:    n,
+		samples: make([]metrics.Sample, len(names)),
+		last:    make([]*metrics.Float64Histogram, len(names)),
+		windows: make(map[string]*Window),
+	}
+	for i, name := range names {
+		kind, ok := kinds[name]
+		if !ok {
+			return nil, fmt.Errorf("histogram: unknown runtime metric %q", name)
+		}
+		if kind != metrics.KindFloat64Histogram {
+			return nil, fmt.Errorf("histogram: runtime metric %q is not a histogram", name)
+		}
+		c.samples[i].Name = name
+		c.windows[name] = NewWindow(periods, func() Histogram { return NewHistogram(n, 1) })
+	}
+	c.read = c.readMetrics
+	return c, nil
+}
+
+func (c *RuntimeCollector) readMetrics() []*metrics.Float64Histogram {
+	metrics.Read(c.samples)
+	r := make([]*metrics.Float64Histogram, len(c.samples))
+	for i, s := range c.samples {
+		if s.Value.Kind() == metrics.KindFloat64Histogram {
+			r[i] = s.Value.Float64Histogram()
+		}
+	}
+	return r
+}
+
+// Collect reads the runtime metrics and adds everything observed since the
+// previous collection as a new period. The first collection includes all
+// values observed since the process started. A metric whose values cannot be
+// converted gets an empty period and Collect returns the first such error.
+func (c *RuntimeCollector) Collect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var first error
+	for i, current := range c.read() {
+		if current == nil {
+			continue
+		}
+
+		counts := make([]float64, len(current.Counts))
+		for j := range counts {
+			counts[j] = float64(current.Counts[j])
+			if last := c.last[i]; last != nil && len(last.Counts) == len(current.Counts) {
+				counts[j] -= float64(last.Counts[j])
+			}
+		}
+		// metrics.Read reuses the histogram on the next call.
+		c.last[i] = &metrics.Float64Histogram{
+			Counts:  append([]uint64(nil), current.Counts...),
+			Buckets: current.Buckets,
+		}
+
+		name := c.samples[i].Name
+		w := c.windows[name]
+		w.Rotate()
+		delta, err := NewHistogramFromBuckets(c.bins, current.Buckets, counts)
+		if err != nil {
+			if first == nil {
+				first = fmt.Errorf("histogram: runtime metric %q: %v", name, err)
+			}
+			continue
+		}
+		w.Merge(delta)
+	}
+	return first
+}
+
+// Start collects every interval in a new goroutine until Stop is called. The
+// last collection error is returned by Err.
+func (c *RuntimeCollector) Start(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		return
+	}
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.Collect(); err != nil {
+					c.mu.Lock()
+					c.err = err
+					c.mu.Unlock()
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(c.stop, c.done)
+}
+
+// Stop stops collecting, waiting for a running collection to finish.
+func (c *RuntimeCollector) Stop() {
+	c.mu.Lock()
+	stop, done := c.stop, c.done
+	c.stop, c.done = nil, nil
+	c.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Err returns the last error of the collections started by Start, or nil.
+func (c *RuntimeCollector) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Histogram returns the values of the named metric observed within the window,
+// or nil if the metric is not collected.
+func (c *RuntimeCollector) Histogram(name string) Histogram {
+	w, ok := c.windows[name]
+	if !ok {
+		return nil
+	}
+	return w.Snapshot()
+}
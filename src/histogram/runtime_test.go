@@ -0,0 +1,137 @@
+package histogram
+
+import (
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"testing"
+	"time"
+)
+
+func TestNewHistogramFromRuntime(t *testing.T) {
+	h, err := NewHistogramFromRuntime(16, &metrics.Float64Histogram{
+		Counts:  []uint64{1, 4, 4, 1},
+		Buckets: []float64{math.Inf(-1), 0, 1, 2, math.Inf(1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Count() != 10 {
+		t.Errorf("Count mismatch %v != 10", h.Count())
+	}
+	if q := MarginalQuantile(h, 0.5); !approx(q[0], 1) {
+		t.Errorf("Median mismatch %v != 1", q)
+	}
+	if min, max := h.Min(), h.Max(); min[0] != 0 || max[0] != 2 {
+		t.Errorf("Range mismatch %v %v", min, max)
+	}
+	if _, err := NewHistogramFromRuntime(0, &metrics.Float64Histogram{Counts: []uint64{1}, Buckets: []float64{0, 1}}); err == nil {
+		t.Errorf("Expected error for 0 bins")
+	}
+}
+
+func TestRuntimeCollector(t *testing.T) {
+	const name = "/sched/pauses/total/gc:seconds"
+	c, err := NewRuntimeCollector(32, 2, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Collect(); err != nil {
+		t.Fatal(err)
+	}
+	runtime.GC()
+	if err := c.Collect(); err != nil {
+		t.Fatal(err)
+	}
+	if h := c.Histogram(name); h.Count() < 1 {
+		t.Errorf("Expected GC pauses, got count %v", h.Count())
+	}
+
+	c.Start(time.Millisecond)
+	runtime.GC()
+	time.Sleep(20 * time.Millisecond)
+	c.Stop()
+	c.Stop()
+	if err := c.Err(); err != nil {
+		t.Error(err)
+	}
+
+	if c.Histogram("/unknown") != nil {
+		t.Errorf("Expected nil histogram for unknown metric")
+	}
+	if _, err := NewRuntimeCollector(32, 2, "/unknown:seconds"); err == nil {
+		t.Errorf("Expected error for unknown metric")
+	}
+	if _, err := NewRuntimeCollector(32, 2, "/gc/heap/goal:bytes"); err == nil {
+		t.Errorf("Expected error for non histogram metric")
+	}
+	if _, err := NewRuntimeCollector(0, 2, name); err == nil {
+		t.Errorf("Expected error for 0 bins")
+	}
+}
+
+func TestRuntimeCollectorWindow(t *testing.T) {
+	const name = "/sched/pauses/total/gc:seconds"
+	c, err := NewRuntimeCollector(32, 2, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the runtime with cumulative counts under the test's control.
+	current := &metrics.Float64Histogram{
+		Counts:  []uint64{0, 0, 0},
+		Buckets: []float64{0, 1, 2, 3},
+	}
+	c.read = func() []*metrics.Float64Histogram {
+		return []*metrics.Float64Histogram{current}
+	}
+
+	for _, test := range []struct {
+		counts []uint64
+		count  float64
+	}{
+		{[]uint64{1, 2, 0}, 3},
+		{[]uint64{1, 4, 1}, 6},
+		{[]uint64{1, 4, 1}, 3},
+		{[]uint64{1, 4, 1}, 0},
+		{[]uint64{2, 4, 1}, 1},
+	} {
+		current.Counts = test.counts
+		if err := c.Collect(); err != nil {
+			t.Fatal(err)
+		}
+		if h := c.Histogram(name); h.Count() != test.count {
+			t.Errorf("Count after %v mismatch %v != %v", test.counts, h.Count(), test.count)
+		}
+	}
+
+	// Counts that do not match the buckets are reported, leaving an empty
+	// period.
+	current = &metrics.Float64Histogram{
+		Counts:  []uint64{1, 2, 3},
+		Buckets: []float64{0, 1},
+	}
+	if err := c.Collect(); err == nil {
+		t.Errorf("Expected error for mismatched buckets")
+	}
+	if h := c.Histogram(name); h.Count() != 1 {
+		t.Errorf("Count after error mismatch %v != 1", h.Count())
+	}
+}
+
+func TestWindow(t *testing.T) {
+	w := NewWindow(3, func() Histogram { return NewHistogram(8, 1) })
+	for i := 0; i < 5; i++ {
+		w.Add([]float64{float64(i)})
+		w.Rotate()
+	}
+	// Rotating after every Add leaves the last two values and an empty period.
+	h := w.Snapshot()
+	if h.Count() != 2 {
+		t.Errorf("Count mismatch %v != 2", h.Count())
+	}
+	if mean := h.Mean(); !approx(mean[0], 3.5) {
+		t.Errorf("Mean mismatch %v != 3.5", mean)
+	}
+}
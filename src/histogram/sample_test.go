@@ -25,6 +25,11 @@ func TestSampleData(t *testing.T) {
 		fmt.Println("DIMENSION", d+1)
 		_mean, _variance, _count, _, _, _, _, _ := compute(1, data)
 
+		exact := NewExactHistogram(d + 1)
+		for _, val := range data {
+			exact.Add(val)
+		}
+
 		for _, b := range []int{32, 64, 128} {
 			fmt.Println("BINS", b)
 
@@ -53,48 +58,55 @@ func TestSampleData(t *testing.T) {
 			// Accuracy of 0.01 for a min bin value of at least 32.
 			// For higher accuracy may need to increase bin count at the cost of increased time for merging bins
 			fmt.Println("CDF MEAN", cdf0)
-			if !approx2(cdf0, calculate(data, mean, count)) {
+			if !approx2(cdf0, exact.CDF(mean)) {
 				t.Errorf("CDF of size %d dimension %d incorrect %v", b, d+1, cdf0)
 			}
 
 			fmt.Println("CDF MEAN - 2SD", cdf1)
-			if !approx2(cdf1, calculate(data, subtract(mean, multiply(2, sd)), count)) {
+			if !approx2(cdf1, exact.CDF(subtract(mean, multiply(2, sd)))) {
 				t.Errorf("CDF of size %d dimension %d incorrect %v", b, d+1, cdf1)
 			}
 
 			fmt.Println("CDF MEAN - SD", cdf2)
-			if !approx2(cdf2, calculate(data, subtract(mean, sd), count)) {
+			if !approx2(cdf2, exact.CDF(subtract(mean, sd))) {
 				t.Errorf("CDF of size %d dimension %d incorrect %v", b, d+1, cdf2)
 			}
 
 			fmt.Println("CDF MEAN + SD", cdf3)
-			if !approx2(cdf3, calculate(data, add(mean, sd), count)) {
+			if !approx2(cdf3, exact.CDF(add(mean, sd))) {
 				t.Errorf("CDF of size %d dimension %d incorrect %v", b, d+1, cdf3)
 			}
 
 			fmt.Println("CDF MEAN + 2SD", cdf4)
-			if !approx2(cdf4, calculate(data, add(mean, multiply(2, sd)), count)) {
+			if !approx2(cdf4, exact.CDF(add(mean, multiply(2, sd)))) {
 				t.Errorf("CDF of size %d dimension %d incorrect %v", b, d+1, cdf4)
 			}
 		}
 	}
 }
 
-func less(x, y []float64) bool {
-	for i := range x {
-		if x[i] > y[i] {
-			return false
-		}
+func TestSampleDataTDigest(t *testing.T) {
+	exact := NewExactHistogram(1)
+	for _, val := range dataDimension1 {
+		exact.Add(val)
 	}
-	return true
-}
+	mean := exact.Mean()
+	sd := sqrt(exact.Variance())
 
-func calculate(data [][]float64, compare []float64, count float64) float64 {
-	sum := 0.0
-	for i := range data {
-		if less(data[i], compare) {
-			sum += 1
+	for _, compression := range []float64{32, 64, 128} {
+		h := NewTDigest(compression)
+		for _, val := range dataDimension1 {
+			h.Add(val)
+		}
+
+		if !approx(h.Count(), exact.Count()) || !approx(h.Mean()[0], mean[0]) || !approx(h.Variance()[0], exact.Variance()[0]) {
+			t.Errorf("Moments of compression %v incorrect %v %v %v", compression, h.Count(), h.Mean(), h.Variance())
+		}
+
+		for _, x := range [][]float64{subtract(mean, multiply(2, sd)), subtract(mean, sd), mean, add(mean, sd), add(mean, multiply(2, sd))} {
+			if cdf := h.CDF(x); !approx2(cdf, exact.CDF(x)) {
+				t.Errorf("CDF of compression %v at %v incorrect %v != %v", compression, x, cdf, exact.CDF(x))
+			}
 		}
 	}
-	return sum / count
 }
@@ -0,0 +1,85 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	sortpkg "sort"
+)
+
+// InBin is the distribution of the points within a bin assumed by a Sampler.
+type InBin int
+
+const (
+	// InBinUniform draws points uniformly within the min/max box of the bin,
+	// like Probability and PDF assume. The moments of the points are those of
+	// the boxes, which may differ from Mean and Variance.
+	InBinUniform InBin = iota
+
+	// InBinGaussian draws points from a Gaussian with the mean and variance
+	// of the bin, which preserves Mean and Variance of the histogram.
+	InBinGaussian
+)
+
+// Sampler draws random points from the distribution of a histogram, to drive
+// load tests or simulations. It holds a copy of the bins, so later changes to
+// the histogram do not affect it.
+type Sampler struct {
+	bins       []bin
+	cumulative []float64
+	model      InBin
+}
+
+// NewSampler returns a sampler drawing bins of h in proportion to their count
+// and points within them from model.
+func NewSampler(h Histogram, model InBin) *Sampler {
+	s := &Sampler{model: model}
+	b, ok := h.(boxer)
+	if !ok {
+		return s
+	}
+
+	s.bins = nonempty(b.boxes())
+	s.cumulative = make([]float64, len(s.bins))
+	sum := 0.0
+	for i := range s.bins {
+		sum += s.bins[i].count
+		s.cumulative[i] = sum
+	}
+	return s
+}
+
+// Sample returns a random point, or an empty slice if the histogram was empty.
+func (s *Sampler) Sample(r *rand.Rand) []float64 {
+	if len(s.bins) == 0 {
+		return []float64{}
+	}
+
+	total := s.cumulative[len(s.cumulative)-1]
+	i := sortpkg.SearchFloat64s(s.cumulative, r.Float64()*total)
+	if i == len(s.bins) {
+		i--
+	}
+	b := &s.bins[i]
+
+	x := make([]float64, b.vec.Dimension())
+	for j := range x {
+		switch s.model {
+		case InBinGaussian:
+			// Rounding can leave the variance of merged bins slightly negative.
+			x[j] = b.vec.Value(j) + r.NormFloat64()*math.Sqrt(math.Max(b.variance.Value(j), 0))
+		default:
+			lo, hi := b.min.Value(j), b.max.Value(j)
+			x[j] = lo + r.Float64()*(hi-lo)
+		}
+	}
+	return x
+}
+
+// SampleN returns n random points.
+func (s *Sampler) SampleN(r *rand.Rand, n int) [][]float64 {
+	points := make([][]float64, n)
+	for i := range points {
+		points[i] = s.Sample(r)
+	}
+	return points
+}
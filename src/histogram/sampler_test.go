@@ -0,0 +1,100 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSampler(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h := NewHistogram(64, 2)
+	for i := 0; i < 5000; i++ {
+		x := r.NormFloat64()
+		h.Add([]float64{x, x + r.ExpFloat64()})
+	}
+
+	// Gaussian samples have the moments of the histogram, uniform ones those
+	// of the boxes of its bins.
+	boxMean, boxVariance := make([]float64, 2), make([]float64, 2)
+	for _, b := range h.(*histogram).bins {
+		for j := range boxMean {
+			mid, w := (b.min.Value(j)+b.max.Value(j))/2, b.max.Value(j)-b.min.Value(j)
+			boxMean[j] += b.count * mid / h.Count()
+			boxVariance[j] += b.count * (w*w/12 + mid*mid) / h.Count()
+		}
+	}
+	for j := range boxVariance {
+		boxVariance[j] -= boxMean[j] * boxMean[j]
+	}
+
+	for _, test := range []struct {
+		name           string
+		model          InBin
+		mean, variance []float64
+	}{
+		{"uniform", InBinUniform, boxMean, boxVariance},
+		{"Gaussian", InBinGaussian, h.Mean(), h.Variance()},
+	} {
+		e := NewExactHistogram(2)
+		for _, x := range NewSampler(h, test.model).SampleN(r, 20000) {
+			e.Add(x)
+		}
+		for j := range test.mean {
+			if m := e.Mean()[j]; math.Abs(m-test.mean[j]) > 0.03*math.Sqrt(test.variance[j]) {
+				t.Errorf("%s: mean of dimension %d of samples %v != %v", test.name, j, m, test.mean[j])
+			}
+			if v := e.Variance()[j]; math.Abs(v-test.variance[j]) > 0.05*test.variance[j] {
+				t.Errorf("%s: variance of dimension %d of samples %v != %v", test.name, j, v, test.variance[j])
+			}
+		}
+
+		// Uniform samples stay within the boxes of the bins.
+		if test.model == InBinUniform {
+			if p := h.Probability(e.Min(), e.Max()); e.Min()[0] < h.Min()[0] || e.Max()[1] > h.Max()[1] || p < 0.99 {
+				t.Errorf("%s: samples within %v, %v outside %v, %v", test.name, e.Min(), e.Max(), h.Min(), h.Max())
+			}
+			for _, q := range []float64{0.1, 0.5, 0.9} {
+				x := h.Quantile(q)
+				if a, b := e.CDF(x), h.CDF(x); math.Abs(a-b) > 0.02 {
+					t.Errorf("%s: CDF of samples at %v %v != %v", test.name, x, a, b)
+				}
+			}
+		}
+	}
+
+	// Points of exact histograms are drawn as they are.
+	e := NewExactHistogram(1)
+	for _, v := range []float64{1, 2, 2, 5} {
+		e.Add([]float64{v})
+	}
+	s := NewSampler(e, InBinGaussian)
+	counts := make(map[float64]int)
+	for i := 0; i < 4000; i++ {
+		counts[s.Sample(r)[0]]++
+	}
+	if len(counts) != 3 || math.Abs(float64(counts[2])-2000) > 150 {
+		t.Errorf("Samples of exact histogram %v", counts)
+	}
+
+	if x := NewSampler(NewHistogram(8, 2), InBinUniform).Sample(r); len(x) != 0 {
+		t.Errorf("Sample of empty histogram %v", x)
+	}
+}
+
+func TestSamplerOffset(t *testing.T) {
+	// Merged bins of data with a large offset can round to a slightly
+	// negative variance, which must not produce NaN samples.
+	r := rand.New(rand.NewSource(0))
+	h := NewHistogram(4, 1)
+	for i := 0; i < 1000; i++ {
+		h.Add([]float64{1e6 + r.Float64()*0.01})
+	}
+
+	s := NewSampler(h, InBinGaussian)
+	for i := 0; i < 1000; i++ {
+		if x := s.Sample(r); math.IsNaN(x[0]) || math.Abs(x[0]-1e6) > 10 {
+			t.Fatalf("Sample %v of data near 1e6", x)
+		}
+	}
+}
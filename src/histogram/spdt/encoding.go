@@ -0,0 +1,161 @@
+package spdt
+
+import (
+	"encoding/json"
+	"fmt"
+	"histogram"
+)
+
+type treeJSON struct {
+	Features int      `json:"features"`
+	Options  Options  `json:"options"`
+	Nodes    int      `json:"nodes"`
+	Root     nodeJSON `json:"root"`
+}
+
+type nodeJSON struct {
+	ID        int             `json:"id"`
+	Feature   int             `json:"feature,omitempty"`
+	Threshold float64         `json:"threshold,omitempty"`
+	Counts    map[int]float64 `json:"counts"`
+	Left      *nodeJSON       `json:"left,omitempty"`
+	Right     *nodeJSON       `json:"right,omitempty"`
+}
+
+type summaryJSON struct {
+	Features int        `json:"features"`
+	Bins     int        `json:"bins"`
+	Leaves   []leafJSON `json:"leaves"`
+}
+
+type leafJSON struct {
+	ID         int                           `json:"id"`
+	Counts     map[int]float64               `json:"counts"`
+	Histograms map[int][]histogram.Histogram `json:"histograms"`
+}
+
+// leafJSONIn is leafJSON with the histograms left raw for histogram.Unmarshal.
+type leafJSONIn struct {
+	ID         int                       `json:"id"`
+	Counts     map[int]float64           `json:"counts"`
+	Histograms map[int][]json.RawMessage `json:"histograms"`
+}
+
+// MarshalJSON encodes the structure and counts of the tree. Statistics of
+// leaves that have not split yet are not included.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	return json.Marshal(treeJSON{
+		Features: t.features,
+		Options:  t.options,
+		Nodes:    t.nodes,
+		Root:     *newNodeJSON(t.root),
+	})
+}
+
+func (t *Tree) UnmarshalJSON(data []byte) error {
+	var r treeJSON
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	root, err := toNode(&r.Root, r.Features, 0)
+	if err != nil {
+		return err
+	}
+
+	t.features = r.Features
+	t.options = r.Options
+	t.nodes = r.Nodes
+	t.root = root
+	return nil
+}
+
+func newNodeJSON(n *Node) *nodeJSON {
+	r := &nodeJSON{ID: n.ID, Counts: n.Counts}
+	if n.Left != nil {
+		r.Feature, r.Threshold = n.Feature, n.Threshold
+		r.Left, r.Right = newNodeJSON(n.Left), newNodeJSON(n.Right)
+	}
+	return r
+}
+
+// toNode converts and validates a node of a tree of the given features.
+func toNode(r *nodeJSON, features, depth int) (*Node, error) {
+	n := &Node{ID: r.ID, Counts: r.Counts, depth: depth}
+	if n.Counts == nil {
+		n.Counts = make(map[int]float64)
+	}
+	if (r.Left == nil) != (r.Right == nil) {
+		return nil, fmt.Errorf("spdt: node %d has a single child", r.ID)
+	}
+	if r.Left == nil {
+		return n, nil
+	}
+	if r.Feature < 0 || r.Feature >= features {
+		return nil, fmt.Errorf("spdt: node %d splits feature %d, expected below %d", r.ID, r.Feature, features)
+	}
+
+	var err error
+	n.Feature, n.Threshold = r.Feature, r.Threshold
+	if n.Left, err = toNode(r.Left, features, depth+1); err != nil {
+		return nil, err
+	}
+	if n.Right, err = toNode(r.Right, features, depth+1); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// MarshalJSON encodes the summary for sending to the master.
+func (s *Summary) MarshalJSON() ([]byte, error) {
+	r := summaryJSON{
+		Features: s.features,
+		Bins:     s.bins,
+		Leaves:   make([]leafJSON, 0, len(s.leaves)),
+	}
+	for id, l := range s.leaves {
+		r.Leaves = append(r.Leaves, leafJSON{ID: id, Counts: l.counts, Histograms: l.histograms})
+	}
+	return json.Marshal(r)
+}
+
+func (s *Summary) UnmarshalJSON(data []byte) error {
+	var r struct {
+		Features int          `json:"features"`
+		Bins     int          `json:"bins"`
+		Leaves   []leafJSONIn `json:"leaves"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+
+	leaves := make(map[int]*leaf, len(r.Leaves))
+	for _, rl := range r.Leaves {
+		l := newLeaf()
+		for c, count := range rl.Counts {
+			l.counts[c] = count
+		}
+		for c, raw := range rl.Histograms {
+			if len(raw) != r.Features {
+				return fmt.Errorf("spdt: leaf %d has %d histograms for class %d, expected %d", rl.ID, len(raw), c, r.Features)
+			}
+			hs := make([]histogram.Histogram, len(raw))
+			for i := range raw {
+				h, err := histogram.Unmarshal(raw[i])
+				if err != nil {
+					return err
+				}
+				if h.Dimension() != 1 {
+					return fmt.Errorf("spdt: leaf %d has a histogram of dimension %d", rl.ID, h.Dimension())
+				}
+				hs[i] = h
+			}
+			l.histograms[c] = hs
+		}
+		leaves[rl.ID] = l
+	}
+
+	s.features = r.Features
+	s.bins = r.Bins
+	s.leaves = leaves
+	return nil
+}
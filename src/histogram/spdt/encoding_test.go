@@ -0,0 +1,58 @@
+package spdt
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	tree := New(3, Options{MaxDepth: 4})
+	train(tree, 2, 1000, 3)
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Tree
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		x, _ := point(r)
+		if restored.Predict(x) != tree.Predict(x) {
+			t.Fatalf("Prediction at %v mismatch after Unmarshal", x)
+		}
+	}
+
+	// Summaries sent as JSON grow both trees alike.
+	w := NewWorker(tree)
+	for i := 0; i < 1000; i++ {
+		w.Add(point(r))
+	}
+	data, err = json.Marshal(w.Summary())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s1, s2 Summary
+	if err := json.Unmarshal(data, &s1); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &s2); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := tree.Grow(&s1), restored.Grow(&s2); a != b {
+		t.Errorf("Splits mismatch %d != %d", a, b)
+	}
+	a, _ := json.Marshal(tree)
+	b, _ := json.Marshal(&restored)
+	if string(a) != string(b) {
+		t.Errorf("Trees mismatch after Grow\n%s\n%s", a, b)
+	}
+
+	if err := json.Unmarshal([]byte(`{"features":1,"root":{"id":1,"left":{"id":2}}}`), &restored); err == nil {
+		t.Errorf("Unmarshal of node with a single child succeeded")
+	}
+}
@@ -0,0 +1,309 @@
+// Package spdt trains classification trees from streaming labelled vectors,
+// following Ben-Haim & Yom-Tov's A Streaming Parallel Decision Tree Algorithm.
+//
+// Workers route their share of the data to the leaves of the current tree and
+// summarise it in one histogram per leaf, class and feature. The master
+// merges the summaries of all workers into the tree, and splits every leaf at
+// the candidate threshold with the largest impurity gain, using the Uniform
+// and Sum procedures of the histograms. Rounds repeat until no leaf splits.
+//
+//	tree := spdt.New(features, spdt.Options{})
+//	for {
+//		summaries := ... // spdt.NewWorker(tree) per share of the data
+//		if tree.Grow(summaries...) == 0 {
+//			break
+//		}
+//	}
+//	label := tree.Predict(x)
+package spdt
+
+import (
+	"histogram"
+	"math"
+	"sort"
+)
+
+// Options configure the growth of a tree. Zero values select the defaults.
+type Options struct {
+	// Bins is the number of bins of every histogram, 32 by default.
+	Bins int
+
+	// Candidates is the number of intervals between candidate thresholds per
+	// feature, 16 by default.
+	Candidates int
+
+	// MaxDepth limits the depth of the tree, unlimited by default.
+	MaxDepth int
+
+	// MinCount is the number of points a leaf needs before it splits, 10 by
+	// default.
+	MinCount float64
+
+	// MinGain is the impurity gain a split needs.
+	MinGain float64
+
+	// Criterion is the impurity, "entropy" (the default) or "gini".
+	Criterion string
+}
+
+// Tree is a classification tree. Grow must not run concurrently with Worker
+// methods or Predict.
+type Tree struct {
+	features int
+	options  Options
+	root     *Node
+	nodes    int
+}
+
+// Node is a node of a tree. Inner nodes send x to Left when x[Feature] <=
+// Threshold. Counts holds the estimated number of points per class that
+// reached the node when it was a leaf.
+type Node struct {
+	ID        int
+	Feature   int
+	Threshold float64
+	Left      *Node
+	Right     *Node
+	Counts    map[int]float64
+
+	depth int
+
+	// stats merges the summaries of the leaf since it was created.
+	stats *leaf
+}
+
+// New returns a tree of a single leaf for vectors of the given number of
+// features.
+func New(features int, o Options) *Tree {
+	if o.Bins <= 0 {
+		o.Bins = 32
+	}
+	if o.Candidates <= 0 {
+		o.Candidates = 16
+	}
+	if o.MinCount <= 0 {
+		o.MinCount = 10
+	}
+	if o.Criterion == "" {
+		o.Criterion = "entropy"
+	}
+
+	t := &Tree{features: features, options: o}
+	t.root = t.newNode(0, map[int]float64{})
+	return t
+}
+
+func (t *Tree) newNode(depth int, counts map[int]float64) *Node {
+	t.nodes++
+	return &Node{ID: t.nodes, Counts: counts, depth: depth}
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() *Node {
+	return t.root
+}
+
+// Features returns the number of features of the vectors of the tree.
+func (t *Tree) Features() int {
+	return t.features
+}
+
+// Leaf returns the leaf x is routed to, or nil if x has the wrong number of
+// features.
+func (t *Tree) Leaf(x []float64) *Node {
+	if len(x) != t.features {
+		return nil
+	}
+	n := t.root
+	for n.Left != nil {
+		if x[n.Feature] <= n.Threshold {
+			n = n.Left
+		} else {
+			n = n.Right
+		}
+	}
+	return n
+}
+
+// Predict returns the most frequent class of the leaf x is routed to, or -1
+// if x has the wrong number of features or the leaf has seen no points.
+func (t *Tree) Predict(x []float64) int {
+	if len(x) != t.features {
+		return -1
+	}
+	return t.Leaf(x).Label()
+}
+
+// Label returns the most frequent class of the node, the smallest on ties, or
+// -1 if it has seen no points.
+func (n *Node) Label() int {
+	label, count := -1, 0.0
+	for _, c := range classes(n.Counts) {
+		if n.Counts[c] > count {
+			label, count = c, n.Counts[c]
+		}
+	}
+	return label
+}
+
+// Grow merges the worker summaries into the leaves and splits every leaf
+// whose best split has enough gain. It returns the number of leaves split.
+// Summaries of leaves that are no longer part of the tree are ignored.
+func (t *Tree) Grow(summaries ...*Summary) int {
+	leaves := make(map[int]*Node)
+	t.walk(t.root, func(n *Node) {
+		if n.Left == nil {
+			leaves[n.ID] = n
+		}
+	})
+
+	for _, s := range summaries {
+		if s.features != t.features {
+			continue
+		}
+		for id, l := range s.leaves {
+			n, ok := leaves[id]
+			if !ok {
+				continue
+			}
+			if n.stats == nil {
+				n.stats = newLeaf()
+			}
+			n.stats.merge(l, t.options.Bins)
+			for c, count := range l.counts {
+				n.Counts[c] += count
+			}
+		}
+	}
+
+	ids := make([]int, 0, len(leaves))
+	for id := range leaves {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	splits := 0
+	for _, id := range ids {
+		if t.split(leaves[id]) {
+			splits++
+		}
+	}
+	return splits
+}
+
+// split splits a leaf at its best candidate threshold.
+func (t *Tree) split(n *Node) bool {
+	if n.stats == nil || (t.options.MaxDepth > 0 && n.depth >= t.options.MaxDepth) {
+		return false
+	}
+	counts := n.stats.counts
+	total := 0.0
+	for _, c := range counts {
+		total += c
+	}
+	if total < t.options.MinCount || len(counts) < 2 {
+		return false
+	}
+
+	impurity := t.impurity(counts)
+	best, feature, threshold := t.options.MinGain, -1, 0.0
+	var left map[int]float64
+	for i := 0; i < t.features; i++ {
+		// Candidates split the points of all classes into equal parts.
+		h := histogram.NewHistogram(t.options.Bins, 1)
+		for _, c := range classes(counts) {
+			h.Merge(n.stats.histograms[c][i])
+		}
+
+		for _, u := range histogram.Uniform(h, 0, t.options.Candidates) {
+			l, r := make(map[int]float64), make(map[int]float64)
+			nl, nr := 0.0, 0.0
+			for c, count := range counts {
+				l[c] = math.Min(math.Max(histogram.Sum(n.stats.histograms[c][i], 0, u), 0), count)
+				r[c] = count - l[c]
+				nl += l[c]
+				nr += r[c]
+			}
+			if nl == 0 || nr == 0 {
+				continue
+			}
+
+			gain := impurity - nl/total*t.impurity(l) - nr/total*t.impurity(r)
+			if gain > best {
+				best, feature, threshold, left = gain, i, u, l
+			}
+		}
+	}
+	if feature < 0 {
+		return false
+	}
+
+	right := make(map[int]float64)
+	for c, count := range counts {
+		right[c] = count - left[c]
+	}
+	n.Feature, n.Threshold = feature, threshold
+	n.Left = t.newNode(n.depth+1, left)
+	n.Right = t.newNode(n.depth+1, right)
+	n.stats = nil
+	return true
+}
+
+func (t *Tree) impurity(counts map[int]float64) float64 {
+	if t.options.Criterion == "gini" {
+		return Gini(counts)
+	}
+	return Entropy(counts)
+}
+
+func (t *Tree) walk(n *Node, f func(n *Node)) {
+	f(n)
+	if n.Left != nil {
+		t.walk(n.Left, f)
+		t.walk(n.Right, f)
+	}
+}
+
+// Entropy returns the entropy in bits of the class distribution.
+func Entropy(counts map[int]float64) float64 {
+	total := 0.0
+	for _, c := range counts {
+		total += c
+	}
+
+	e := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := c / total
+			e -= p * math.Log2(p)
+		}
+	}
+	return e
+}
+
+// Gini returns the Gini impurity of the class distribution.
+func Gini(counts map[int]float64) float64 {
+	total := 0.0
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	g := 1.0
+	for _, c := range counts {
+		g -= (c / total) * (c / total)
+	}
+	return g
+}
+
+// classes returns the classes of counts in increasing order.
+func classes(counts map[int]float64) []int {
+	r := make([]int, 0, len(counts))
+	for c := range counts {
+		r = append(r, c)
+	}
+	sort.Ints(r)
+	return r
+}
@@ -0,0 +1,102 @@
+package spdt
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// point returns a point of two classes separated by x0 + x1 > 1, with a
+// third, irrelevant feature and some label noise.
+func point(r *rand.Rand) ([]float64, int) {
+	x := []float64{r.Float64() * 2, r.Float64() * 2, r.NormFloat64()}
+	label := 0
+	if x[0]+x[1] > 1 {
+		label = 1
+	}
+	if r.Float64() < 0.02 {
+		label = 1 - label
+	}
+	return x, label
+}
+
+// train grows a tree with parallel workers, each seeing its own stream.
+func train(t *Tree, workers, points, rounds int) {
+	for round := 0; round < rounds; round++ {
+		summaries := make([]*Summary, workers)
+		var wg sync.WaitGroup
+		for i := range summaries {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(int64(round*workers + i)))
+				w := NewWorker(t)
+				for j := 0; j < points; j++ {
+					w.Add(point(r))
+				}
+				summaries[i] = w.Summary()
+			}(i)
+		}
+		wg.Wait()
+
+		if t.Grow(summaries...) == 0 {
+			return
+		}
+	}
+}
+
+func accuracy(t *Tree) float64 {
+	r := rand.New(rand.NewSource(-1))
+	correct := 0
+	for i := 0; i < 2000; i++ {
+		x, label := point(r)
+		if t.Predict(x) == label {
+			correct++
+		}
+	}
+	return float64(correct) / 2000
+}
+
+func TestTree(t *testing.T) {
+	for _, criterion := range []string{"entropy", "gini"} {
+		tree := New(3, Options{Bins: 16, MaxDepth: 8, MinGain: 0.01, Criterion: criterion})
+		if p := tree.Predict([]float64{0, 0, 0}); p != -1 {
+			t.Errorf("Prediction of empty tree %v", p)
+		}
+		train(tree, 4, 1000, 8)
+
+		a := accuracy(tree)
+		t.Logf("Accuracy with %s: %v", criterion, a)
+		if a < 0.9 {
+			t.Errorf("Accuracy with %s %v", criterion, a)
+		}
+		if tree.Root().Left == nil || tree.Root().Feature == 2 {
+			t.Errorf("Root split on feature %d at %v", tree.Root().Feature, tree.Root().Threshold)
+		}
+		if l := tree.Leaf([]float64{0, 0}); l != nil {
+			t.Errorf("Leaf of 2 features %v", l)
+		}
+		if p := tree.Predict([]float64{0, 0}); p != -1 {
+			t.Errorf("Prediction of short vector %v", p)
+		}
+	}
+}
+
+func TestImpurity(t *testing.T) {
+	for _, c := range []struct {
+		counts        map[int]float64
+		entropy, gini float64
+	}{
+		{map[int]float64{0: 10}, 0, 0},
+		{map[int]float64{0: 5, 1: 5}, 1, 0.5},
+		{map[int]float64{0: 1, 1: 1, 2: 1, 3: 1}, 2, 0.75},
+	} {
+		if e := Entropy(c.counts); math.Abs(e-c.entropy) > 1e-9 {
+			t.Errorf("Entropy(%v) %v != %v", c.counts, e, c.entropy)
+		}
+		if g := Gini(c.counts); math.Abs(g-c.gini) > 1e-9 {
+			t.Errorf("Gini(%v) %v != %v", c.counts, g, c.gini)
+		}
+	}
+}
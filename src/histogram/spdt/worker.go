@@ -0,0 +1,100 @@
+package spdt
+
+import (
+	"histogram"
+)
+
+// Worker summarises a share of the data for the master. Workers of the same
+// tree may run concurrently, as long as the tree does not grow meanwhile.
+type Worker struct {
+	tree    *Tree
+	summary *Summary
+}
+
+// Summary holds, per leaf, the number of points of every class and a
+// histogram per class and feature. It is what a worker sends to the master.
+type Summary struct {
+	features int
+	bins     int
+	leaves   map[int]*leaf
+}
+
+type leaf struct {
+	counts     map[int]float64
+	histograms map[int][]histogram.Histogram
+}
+
+// NewWorker returns a worker routing points through t.
+func NewWorker(t *Tree) *Worker {
+	return &Worker{tree: t, summary: newSummary(t.features, t.options.Bins)}
+}
+
+func newSummary(features, bins int) *Summary {
+	return &Summary{features: features, bins: bins, leaves: make(map[int]*leaf)}
+}
+
+func newLeaf() *leaf {
+	return &leaf{counts: make(map[int]float64), histograms: make(map[int][]histogram.Histogram)}
+}
+
+// Add adds the point x of class label to the histograms of its leaf. Points
+// with the wrong number of features are ignored.
+func (w *Worker) Add(x []float64, label int) {
+	if len(x) != w.tree.features {
+		return
+	}
+
+	id := w.tree.Leaf(x).ID
+	l, ok := w.summary.leaves[id]
+	if !ok {
+		l = newLeaf()
+		w.summary.leaves[id] = l
+	}
+	hs := l.histogram(label, w.summary.features, w.summary.bins)
+	for i, v := range x {
+		hs[i].Add([]float64{v})
+	}
+	l.counts[label]++
+}
+
+// Summary returns the summary of the points added since the last call, and
+// starts a new one.
+func (w *Worker) Summary() *Summary {
+	s := w.summary
+	w.summary = newSummary(w.tree.features, w.tree.options.Bins)
+	return s
+}
+
+// histogram returns the histograms of a class, creating them if needed.
+func (l *leaf) histogram(label, features, bins int) []histogram.Histogram {
+	hs, ok := l.histograms[label]
+	if !ok {
+		hs = make([]histogram.Histogram, features)
+		for i := range hs {
+			hs[i] = histogram.NewHistogram(bins, 1)
+		}
+		l.histograms[label] = hs
+	}
+	return hs
+}
+
+// merge adds the counts and histograms of o to l, creating histograms of the
+// given number of bins if needed.
+func (l *leaf) merge(o *leaf, bins int) {
+	for c, count := range o.counts {
+		l.counts[c] += count
+	}
+	for c, hs := range o.histograms {
+		mine, ok := l.histograms[c]
+		if !ok {
+			mine = make([]histogram.Histogram, len(hs))
+			for i := range mine {
+				mine[i] = histogram.NewHistogram(bins, 1)
+			}
+			l.histograms[c] = mine
+		}
+		for i := range hs {
+			mine[i].Merge(hs[i])
+		}
+	}
+}
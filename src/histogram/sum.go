@@ -0,0 +1,106 @@
+package histogram
+
+import (
+	"math"
+	sortpkg "sort"
+)
+
+// Sum returns the estimated number of points with a value in dimension dim of
+// at most b, following the Sum procedure of Ben-Haim & Yom-Tov. The marginal
+// histogram is the sorted bin centroids with their counts, half of every bin
+// lies on either side of its centroid, and counts are interpolated linearly
+// between centroids. Sum returns -1 if dim is out of range.
+func Sum(h Histogram, dim int, b float64) float64 {
+	p, m, ok := centroids(h, dim)
+	if !ok {
+		return -1
+	}
+	if len(p) == 0 || b < p[0] {
+		return 0
+	}
+	if b >= p[len(p)-1] {
+		return h.Count()
+	}
+
+	i := sortpkg.Search(len(p), func(i int) bool { return p[i] > b }) - 1
+	return sumAt(p, m, i, b)
+}
+
+// sumAt is the Sum procedure for p[i] <= b < p[i+1].
+func sumAt(p, m []float64, i int, b float64) float64 {
+	mb := m[i] + (m[i+1]-m[i])/(p[i+1]-p[i])*(b-p[i])
+	s := (m[i] + mb) / 2 * (b - p[i]) / (p[i+1] - p[i])
+	for j := 0; j < i; j++ {
+		s += m[j]
+	}
+	return s + m[i]/2
+}
+
+// Uniform returns the n-1 values of dimension dim that split the points into
+// n intervals of equal estimated count, following the Uniform procedure of
+// Ben-Haim & Yom-Tov. Values outside the outer centroids, where Sum is not
+// interpolated, are clamped to them. Uniform returns an empty slice if dim is
+// out of range or the histogram is empty.
+func Uniform(h Histogram, dim int, n int) []float64 {
+	p, m, ok := centroids(h, dim)
+	if !ok || len(p) == 0 || n < 1 {
+		return []float64{}
+	}
+
+	// sums[i] is Sum(p[i]).
+	sums := make([]float64, len(p))
+	total := 0.0
+	for i := range p {
+		sums[i] = total + m[i]/2
+		total += m[i]
+	}
+
+	u := make([]float64, n-1)
+	for j := range u {
+		s := float64(j+1) / float64(n) * total
+		i := sortpkg.Search(len(sums), func(i int) bool { return sums[i] > s }) - 1
+		if i < 0 {
+			u[j] = p[0]
+			continue
+		}
+		if i == len(p)-1 {
+			u[j] = p[i]
+			continue
+		}
+
+		// Solve (m[i] + m[u])/2 * z = d for the fraction z of the way from
+		// p[i] to p[i+1], with m[u] interpolated, i.e. a z^2 + 2 m[i] z - 2d = 0.
+		d := s - sums[i]
+		a := m[i+1] - m[i]
+		z := 2 * d / (m[i] + math.Sqrt(m[i]*m[i]+2*a*d))
+		u[j] = p[i] + (p[i+1]-p[i])*z
+	}
+	return u
+}
+
+// centroids returns the sorted, distinct bin centroids of dimension dim with
+// their counts.
+func centroids(h Histogram, dim int) (p, m []float64, ok bool) {
+	b, ok := h.(boxer)
+	if !ok || dim < 0 || dim >= h.Dimension() {
+		return nil, nil, false
+	}
+
+	bins := make([]bin, 0, len(b.boxes()))
+	for _, x := range b.boxes() {
+		if x.count > 0 {
+			bins = append(bins, x)
+		}
+	}
+	sortpkg.Slice(bins, func(i, j int) bool { return bins[i].vec.Value(dim) < bins[j].vec.Value(dim) })
+
+	for _, x := range bins {
+		if n := len(p); n > 0 && p[n-1] == x.vec.Value(dim) {
+			m[n-1] += x.count
+			continue
+		}
+		p = append(p, x.vec.Value(dim))
+		m = append(m, x.count)
+	}
+	return p, m, true
+}
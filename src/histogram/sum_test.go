@@ -0,0 +1,74 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	h := NewHistogram(8, 1)
+	for _, v := range []float64{1, 1, 3, 3, 3, 3, 6, 6} {
+		h.Add([]float64{v})
+	}
+
+	for _, c := range []struct{ b, sum float64 }{
+		{0.5, 0},
+		{1, 1},
+		{2, 2.25},
+		{3, 4},
+		{6, 8},
+		{7, 8},
+	} {
+		if s := Sum(h, 0, c.b); !approx(s, c.sum) {
+			t.Errorf("Sum(%v) %v != %v", c.b, s, c.sum)
+		}
+	}
+	if s := Sum(h, 1, 3); s != -1 {
+		t.Errorf("Sum of dimension 1 %v != -1", s)
+	}
+
+	if u := Uniform(h, 0, 2); len(u) != 1 || !approx(u[0], 3) {
+		t.Errorf("Uniform(2) %v != [3]", u)
+	}
+	if u := Uniform(NewHistogram(8, 1), 0, 4); len(u) != 0 {
+		t.Errorf("Uniform of empty histogram %v", u)
+	}
+}
+
+func TestUniform(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h := NewHistogram(64, 2)
+	e := NewExactHistogram(2)
+	for i := 0; i < 5000; i++ {
+		x := r.NormFloat64()
+		h.Add([]float64{x, 2 * x})
+		e.Add([]float64{x, 2 * x})
+	}
+
+	for dim := 0; dim < 2; dim++ {
+		u := Uniform(h, dim, 10)
+		if len(u) != 9 {
+			t.Fatalf("Uniform(10) returned %d values", len(u))
+		}
+		for k := range u {
+			q := float64(k+1) / 10
+			if s := Sum(h, dim, u[k]); math.Abs(s-q*h.Count()) > 1e-6 {
+				t.Errorf("Sum(Uniform(10)[%d]) %v != %v", k, s, q*h.Count())
+			}
+
+			x := []float64{math.Inf(1), math.Inf(1)}
+			x[dim] = u[k]
+			if rank := e.CDF(x); math.Abs(rank-q) > 0.02 {
+				t.Errorf("Uniform(10)[%d] of dimension %d %v has rank %v", k, dim, u[k], rank)
+			}
+		}
+	}
+
+	// The second dimension is twice the first, in every bin.
+	for _, b := range []float64{-1.5, -0.2, 0, 0.7, 2} {
+		if s, r := Sum(h, 0, b), Sum(h, 1, 2*b); !approx(s, r) {
+			t.Errorf("Sum of marginals at %v mismatch %v != %v", b, s, r)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package histogram
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+)
+
+// SVGOptions controls the plots written by WriteSVG, WriteMarginalSVG and
+// WriteJointSVG. Zero values select the defaults.
+type SVGOptions struct {
+	// Size is the width and height of every plot in pixels, default 200.
+	Size int
+	// Resolution is the number of intervals per axis, default 40.
+	Resolution int
+	// CDF overlays the marginal CDF on marginal density plots.
+	CDF bool
+	// Boxes overlays the min/max box of every bin, for histograms that
+	// expose their bins.
+	Boxes bool
+}
+
+const svgMargin = 40
+
+func (o SVGOptions) withDefaults() SVGOptions {
+	if o.Size <= 0 {
+		o.Size = 200
+	}
+	if o.Resolution <= 0 {
+		o.Resolution = 40
+	}
+	return o
+}
+
+// WriteSVG writes a scatter matrix of h: marginal densities of every dimension
+// on the diagonal and joint densities of every pair of dimensions off it.
+func WriteSVG(w io.Writer, h Histogram, options SVGOptions) error {
+	options = options.withDefaults()
+	d := h.Dimension()
+	cell := options.Size + svgMargin
+
+	bw := bufio.NewWriter(w)
+	svgHeader(bw, d*cell, d*cell)
+	if h.Count() > 0 {
+		for row := 0; row < d; row++ {
+			for col := 0; col < d; col++ {
+				fmt.Fprintf(bw, "<g class=\"panel\" transform=\"translate(%d,%d)\">\n", col*cell+svgMargin, row*cell)
+				if row == col {
+					marginalPanel(bw, h, col, options)
+				} else {
+					jointPanel(bw, h, col, row, options)
+				}
+				fmt.Fprintln(bw, "</g>")
+			}
+		}
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// WriteMarginalSVG writes the marginal density of dimension dim.
+func WriteMarginalSVG(w io.Writer, h Histogram, dim int, options SVGOptions) error {
+	if dim < 0 || dim >= h.Dimension() {
+		return fmt.Errorf("histogram: dimension %d out of range", dim)
+	}
+	options = options.withDefaults()
+	cell := options.Size + svgMargin
+
+	bw := bufio.NewWriter(w)
+	svgHeader(bw, cell, cell)
+	if h.Count() > 0 {
+		fmt.Fprintf(bw, "<g class=\"panel\" transform=\"translate(%d,0)\">\n", svgMargin)
+		marginalPanel(bw, h, dim, options)
+		fmt.Fprintln(bw, "</g>")
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// WriteJointSVG writes the joint density of dimensions x and y as a heatmap.
+func WriteJointSVG(w io.Writer, h Histogram, x, y int, options SVGOptions) error {
+	d := h.Dimension()
+	if x < 0 || x >= d || y < 0 || y >= d || x == y {
+		return fmt.Errorf("histogram: invalid dimensions %d, %d", x, y)
+	}
+	options = options.withDefaults()
+	cell := options.Size + svgMargin
+
+	bw := bufio.NewWriter(w)
+	svgHeader(bw, cell, cell)
+	if h.Count() > 0 {
+		fmt.Fprintf(bw, "<g class=\"panel\" transform=\"translate(%d,0)\">\n", svgMargin)
+		jointPanel(bw, h, x, y, options)
+		fmt.Fprintln(bw, "</g>")
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+func svgHeader(w io.Writer, width, height int) {
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"10\">\n", width, height, width, height)
+	fmt.Fprintf(w, "<rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", width, height)
+}
+
+// marginalPanel draws the density of dimension dim as bars scaled to the
+// densest interval, with the CDF from 0 to 1 over the full height.
+func marginalPanel(w io.Writer, h Histogram, dim int, options SVGOptions) {
+	size := float64(options.Size)
+	edges, mass := intervals(h, dim, options.Resolution)
+	peak := 0.0
+	for i := range mass {
+		peak = math.Max(peak, mass[i])
+	}
+
+	step := size / float64(len(mass))
+	for i := range mass {
+		height := mass[i] / peak * size
+		fmt.Fprintf(w, "<rect class=\"density\" x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"steelblue\"/>\n", float64(i)*step, size-height, step, height)
+	}
+
+	lo, hi := edges[0], edges[len(edges)-1]
+	scale := func(x float64) float64 {
+		if hi == lo {
+			return size / 2
+		}
+		return (x - lo) / (hi - lo) * size
+	}
+
+	if options.Boxes {
+		if b, ok := h.(boxer); ok {
+			// Each bin is drawn at the height of its own uniform density.
+			width := (hi - lo) / float64(len(mass))
+			for _, bin := range b.boxes() {
+				x0, x1 := scale(bin.min.Value(dim)), scale(bin.max.Value(dim))
+				height := 0.0
+				if x1 > x0 {
+					height = math.Min(bin.count/h.Count()*width/(bin.max.Value(dim)-bin.min.Value(dim))/peak, 1) * size
+				}
+				fmt.Fprintf(w, "<rect class=\"box\" x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"none\" stroke=\"crimson\" stroke-opacity=\"0.6\"/>\n", x0, size-height, math.Max(x1-x0, 0.5), height)
+			}
+		}
+	}
+
+	if options.CDF {
+		fmt.Fprint(w, "<polyline class=\"cdf\" fill=\"none\" stroke=\"black\" points=\"")
+		cdf := 0.0
+		fmt.Fprintf(w, "%.2f,%.2f", 0.0, size)
+		for i := range mass {
+			cdf += mass[i]
+			fmt.Fprintf(w, " %.2f,%.2f", float64(i+1)*step, size-cdf*size)
+		}
+		fmt.Fprintln(w, "\"/>")
+	}
+
+	svgFrame(w, size)
+	svgAxis(w, size, fmt.Sprintf("dimension %d", dim), lo, hi)
+}
+
+// jointPanel draws the joint density of dimensions x and y as cells shaded by
+// their mass relative to the densest cell.
+func jointPanel(w io.Writer, h Histogram, x, y int, options SVGOptions) {
+	size := float64(options.Size)
+	n := options.Resolution
+	xedges, yedges, mass := grid(h, x, y, n, n)
+	peak := 0.0
+	for r := range mass {
+		for c := range mass[r] {
+			peak = math.Max(peak, mass[r][c])
+		}
+	}
+
+	step := size / float64(n)
+	for r := range mass {
+		for c := range mass[r] {
+			if mass[r][c] <= 0 {
+				continue
+			}
+			fmt.Fprintf(w, "<rect class=\"density\" x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"steelblue\" fill-opacity=\"%.3f\"/>\n", float64(c)*step, size-float64(r+1)*step, step, step, mass[r][c]/peak)
+		}
+	}
+
+	if options.Boxes {
+		if b, ok := h.(boxer); ok {
+			xlo, xhi := xedges[0], xedges[n]
+			ylo, yhi := yedges[0], yedges[n]
+			scale := func(v, lo, hi float64) float64 {
+				if hi == lo {
+					return size / 2
+				}
+				return (v - lo) / (hi - lo) * size
+			}
+			for _, bin := range b.boxes() {
+				x0, x1 := scale(bin.min.Value(x), xlo, xhi), scale(bin.max.Value(x), xlo, xhi)
+				y0, y1 := scale(bin.min.Value(y), ylo, yhi), scale(bin.max.Value(y), ylo, yhi)
+				fmt.Fprintf(w, "<rect class=\"box\" x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"none\" stroke=\"crimson\" stroke-opacity=\"0.6\"/>\n", x0, size-y1, math.Max(x1-x0, 0.5), math.Max(y1-y0, 0.5))
+			}
+		}
+	}
+
+	svgFrame(w, size)
+	svgAxis(w, size, fmt.Sprintf("dimension %d", x), xedges[0], xedges[n])
+	fmt.Fprintf(w, "<text x=\"-4\" y=\"%.2f\" text-anchor=\"end\">%s</text>\n", size, formatLabel(yedges[0]))
+	fmt.Fprintf(w, "<text x=\"-4\" y=\"10\" text-anchor=\"end\">%s</text>\n", formatLabel(yedges[n]))
+	fmt.Fprintf(w, "<text transform=\"translate(-30,%.2f) rotate(-90)\" text-anchor=\"middle\">dimension %d</text>\n", size/2, y)
+}
+
+func svgFrame(w io.Writer, size float64) {
+	fmt.Fprintf(w, "<rect width=\"%.2f\" height=\"%.2f\" fill=\"none\" stroke=\"gray\"/>\n", size, size)
+}
+
+func svgAxis(w io.Writer, size float64, label string, lo, hi float64) {
+	fmt.Fprintf(w, "<text x=\"0\" y=\"%.2f\">%s</text>\n", size+12, formatLabel(lo))
+	fmt.Fprintf(w, "<text x=\"%.2f\" y=\"%.2f\" text-anchor=\"end\">%s</text>\n", size, size+12, formatLabel(hi))
+	fmt.Fprintf(w, "<text x=\"%.2f\" y=\"%.2f\" text-anchor=\"middle\">%s</text>\n", size/2, size+26, label)
+}
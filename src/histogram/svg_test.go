@@ -0,0 +1,78 @@
+package histogram
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestWriteSVG(t *testing.T) {
+	h := NewHistogram(16, 3)
+	for i := 0; i < 500; i++ {
+		h.Add([]float64{rand.NormFloat64(), rand.NormFloat64(), rand.Float64()})
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, h, SVGOptions{Resolution: 10, Boxes: true, CDF: true}); err != nil {
+		t.Fatal(err)
+	}
+	classes := svgClasses(t, buf.Bytes())
+	if classes["panel"] != 9 {
+		t.Errorf("Expected 9 panels, got %d", classes["panel"])
+	}
+	if classes["cdf"] != 3 {
+		t.Errorf("Expected 3 CDF lines, got %d", classes["cdf"])
+	}
+	if classes["box"] != 9*16 {
+		t.Errorf("Expected %d bin boxes, got %d", 9*16, classes["box"])
+	}
+
+	buf.Reset()
+	if err := WriteMarginalSVG(&buf, h, 1, SVGOptions{Resolution: 10}); err != nil {
+		t.Fatal(err)
+	}
+	classes = svgClasses(t, buf.Bytes())
+	if classes["panel"] != 1 || classes["density"] != 10 || classes["box"] != 0 || classes["cdf"] != 0 {
+		t.Errorf("Unexpected marginal plot %v", classes)
+	}
+
+	buf.Reset()
+	if err := WriteJointSVG(&buf, h, 0, 2, SVGOptions{Boxes: true}); err != nil {
+		t.Fatal(err)
+	}
+	classes = svgClasses(t, buf.Bytes())
+	if classes["panel"] != 1 || classes["density"] == 0 || classes["box"] != 16 {
+		t.Errorf("Unexpected joint plot %v", classes)
+	}
+
+	if err := WriteJointSVG(&buf, h, 1, 1, SVGOptions{}); err == nil {
+		t.Errorf("Expected error for identical dimensions")
+	}
+	if err := WriteMarginalSVG(&buf, h, 3, SVGOptions{}); err == nil {
+		t.Errorf("Expected error for dimension out of range")
+	}
+}
+
+// svgClasses parses an SVG document and counts elements by class.
+func svgClasses(t *testing.T, data []byte) map[string]int {
+	classes := map[string]int{}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return classes
+		}
+		if err != nil {
+			t.Fatalf("Invalid SVG: %v\n%s", err, data)
+		}
+		if e, ok := token.(xml.StartElement); ok {
+			for _, a := range e.Attr {
+				if a.Name.Local == "class" {
+					classes[a.Value]++
+				}
+			}
+		}
+	}
+}
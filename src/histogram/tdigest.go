@@ -0,0 +1,296 @@
+package histogram
+
+import (
+	"fmt"
+	"math"
+	sortpkg "sort"
+	"sync"
+)
+
+// tdigest is a merging t-digest (Dunning & Ertl, Computing Extremely Accurate
+// Quantiles Using t-Digests) for 1-D data. Centroids are bins, so they also
+// keep their variance and min/max box, but are merged by the k1 scale
+// function, which keeps centroids small in the tails.
+type tdigest struct {
+	compression float64
+	centroids   []bin
+	buffer      []bin
+	total       float64
+
+	// mu guards the compression of the buffer by reads, so that concurrent
+	// readers are safe.
+	mu sync.Mutex
+}
+
+// NewTDigest returns a 1-D t-digest. Higher compression keeps more centroids,
+// roughly between compression/2 and compression, for more accurate quantiles.
+func NewTDigest(compression float64) Histogram {
+	if compression < 1 {
+		compression = 1
+	}
+	return &tdigest{
+		compression: compression,
+		centroids:   make([]bin, 0),
+		buffer:      make([]bin, 0),
+	}
+}
+
+func (t *tdigest) Add(values []float64) {
+	if len(values) != 1 {
+		return
+	}
+	m := NewVector([]float64{values[0]})
+	t.buffer = append(t.buffer, bin{count: 1, vec: m, variance: NewVector([]float64{0}), min: m, max: m})
+	t.total++
+	if float64(len(t.buffer)) > 5*t.compression {
+		t.compress()
+	}
+}
+
+// flush compresses the buffer before a read.
+func (t *tdigest) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.compress()
+}
+
+// compress merges the buffered points into the centroids.
+func (t *tdigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+
+	all := append(t.centroids, t.buffer...)
+	sortpkg.SliceStable(all, func(i, j int) bool { return all[i].vec.Value(0) < all[j].vec.Value(0) })
+
+	centroids := make([]bin, 0, len(t.centroids)+1)
+	current := all[0]
+	before := 0.0
+	limit := t.quantileOfScale(t.scale(0) + 1)
+	for _, c := range all[1:] {
+		if (before+current.count+c.count)/t.total <= limit {
+			current = current.Merge(c)
+			continue
+		}
+		centroids = append(centroids, current)
+		before += current.count
+		limit = t.quantileOfScale(t.scale(before/t.total) + 1)
+		current = c
+	}
+	t.centroids = append(centroids, current)
+	t.buffer = t.buffer[:0]
+}
+
+// scale is the k1 scale function, k(q) = δ/2π asin(2q - 1).
+func (t *tdigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*math.Min(math.Max(q, 0), 1)-1)
+}
+
+func (t *tdigest) quantileOfScale(k float64) float64 {
+	if k >= t.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/t.compression) + 1) / 2
+}
+
+func (t *tdigest) Mean() []float64 {
+	t.flush()
+	if t.total == 0 {
+		return []float64{}
+	}
+
+	sum := 0.0
+	for _, c := range t.centroids {
+		sum += c.count * c.vec.Value(0)
+	}
+	return []float64{sum / t.total}
+}
+
+func (t *tdigest) Variance() []float64 {
+	t.flush()
+	if t.total == 0 {
+		return []float64{}
+	}
+
+	mean := t.Mean()[0]
+	sum := 0.0
+	for _, c := range t.centroids {
+		sum += c.count * (c.variance.Value(0) + c.vec.Value(0)*c.vec.Value(0))
+	}
+	return []float64{sum/t.total - mean*mean}
+}
+
+func (t *tdigest) Min() []float64 {
+	t.flush()
+	if t.total == 0 {
+		return []float64{}
+	}
+
+	// Centroids are ordered by mean, but their boxes may overlap.
+	min := math.Inf(1)
+	for _, c := range t.centroids {
+		min = math.Min(min, c.min.Value(0))
+	}
+	return []float64{min}
+}
+
+func (t *tdigest) Max() []float64 {
+	t.flush()
+	if t.total == 0 {
+		return []float64{}
+	}
+
+	max := math.Inf(-1)
+	for _, c := range t.centroids {
+		max = math.Max(max, c.max.Value(0))
+	}
+	return []float64{max}
+}
+
+// CDF interpolates linearly between centroid means, assigning half of every
+// centroid to either side of its mean, and between the outer centroids and
+// Min and Max.
+func (t *tdigest) CDF(x []float64) float64 {
+	if len(x) != 1 {
+		return -1
+	}
+	t.flush()
+	if t.total == 0 {
+		return math.NaN()
+	}
+
+	v := x[0]
+	c := t.centroids
+	min, max := t.Min()[0], t.Max()[0]
+	first, last := c[0], c[len(c)-1]
+	switch {
+	case v < min:
+		return 0
+	case v >= max:
+		return 1
+	case v < first.vec.Value(0):
+		return (v - min) / (first.vec.Value(0) - min) * first.count / 2 / t.total
+	case v >= last.vec.Value(0):
+		return 1 - (max-v)/(max-last.vec.Value(0))*last.count/2/t.total
+	}
+
+	cumulative := first.count / 2
+	for i := 0; i+1 < len(c); i++ {
+		step := (c[i].count + c[i+1].count) / 2
+		if v < c[i+1].vec.Value(0) {
+			return (cumulative + (v-c[i].vec.Value(0))/(c[i+1].vec.Value(0)-c[i].vec.Value(0))*step) / t.total
+		}
+		cumulative += step
+	}
+	return 1
+}
+
+// Quantile inverts CDF.
+func (t *tdigest) Quantile(q float64) []float64 {
+	t.flush()
+	if t.total == 0 {
+		return []float64{}
+	}
+
+	index := math.Min(math.Max(q, 0), 1) * t.total
+	c := t.centroids
+	min, max := t.Min()[0], t.Max()[0]
+	first, last := c[0], c[len(c)-1]
+	if index <= first.count/2 {
+		return []float64{min + (first.vec.Value(0)-min)*index/(first.count/2)}
+	}
+	if index >= t.total-last.count/2 {
+		return []float64{last.vec.Value(0) + (max-last.vec.Value(0))*(index-(t.total-last.count/2))/(last.count/2)}
+	}
+
+	cumulative := first.count / 2
+	for i := 0; i+1 < len(c); i++ {
+		step := (c[i].count + c[i+1].count) / 2
+		if cumulative+step >= index {
+			return []float64{c[i].vec.Value(0) + (index-cumulative)/step*(c[i+1].vec.Value(0)-c[i].vec.Value(0))}
+		}
+		cumulative += step
+	}
+	return []float64{max}
+}
+
+// Merge adds the bins of o, such as the centroids of another t-digest.
+func (t *tdigest) Merge(o Histogram) {
+	b, ok := o.(boxer)
+	if !ok || o.Dimension() != 1 {
+		return
+	}
+	if other, ok := o.(*tdigest); ok {
+		other.flush()
+	}
+
+	for _, c := range b.boxes() {
+		if c.count > 0 {
+			t.buffer = append(t.buffer, c)
+		}
+	}
+	t.total += o.Count()
+	t.compress()
+}
+
+func (t *tdigest) Probability(lo, hi []float64) float64 {
+	if len(lo) != 1 || len(hi) != 1 {
+		return -1
+	}
+	if !(lo[0] < hi[0]) {
+		return 0
+	}
+	return math.Max(t.CDF(hi)-t.CDF(lo), 0)
+}
+
+// CDFWithBounds bounds the CDF by the min/max boxes of the centroids, like the
+// native histogram.
+func (t *tdigest) CDFWithBounds(x []float64) (lower, upper float64) {
+	if len(x) != 1 {
+		return -1, -1
+	}
+	t.flush()
+	if t.total == 0 {
+		return 0, 0
+	}
+
+	for _, c := range t.centroids {
+		if x[0] >= c.max.Value(0) {
+			lower += c.count
+			upper += c.count
+		} else if x[0] >= c.min.Value(0) {
+			upper += c.count
+		}
+	}
+	return lower / t.total, upper / t.total
+}
+
+func (t *tdigest) QuantileWithBounds(q float64) (lower, upper []float64) {
+	t.flush()
+	h := histogram{bins: t.centroids, total: t.total, dimension: 1}
+	return h.QuantileWithBounds(q)
+}
+
+func (t *tdigest) String() (str string) {
+	t.flush()
+	str += fmt.Sprintln("Total:", t.total)
+
+	for _, c := range t.centroids {
+		str += fmt.Sprintln(c.vec.String(), c.min.String(), c.max.String(), "\t", c.count)
+	}
+
+	return
+}
+
+func (t *tdigest) Count() float64 {
+	return t.total
+}
+
+func (t *tdigest) Dimension() int {
+	return 1
+}
+
+func (t *tdigest) boxes() []bin {
+	t.flush()
+	return t.centroids
+}
@@ -0,0 +1,169 @@
+package histogram
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestTDigest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h := NewTDigest(100)
+	e := NewExactHistogram(1)
+	for i := 0; i < 10000; i++ {
+		v := []float64{math.Exp(r.NormFloat64())}
+		h.Add(v)
+		e.Add(v)
+	}
+
+	if h.Count() != e.Count() || h.Min()[0] != e.Min()[0] || h.Max()[0] != e.Max()[0] {
+		t.Errorf("Count or range mismatch %v %v %v != %v %v %v", h.Count(), h.Min(), h.Max(), e.Count(), e.Min(), e.Max())
+	}
+	if !approx(h.Mean()[0], e.Mean()[0]) || !approx(h.Variance()[0], e.Variance()[0]) {
+		t.Errorf("Moments mismatch %v %v != %v %v", h.Mean(), h.Variance(), e.Mean(), e.Variance())
+	}
+	if n := len(h.(*tdigest).centroids); n > 100 {
+		t.Errorf("Too many centroids %d", n)
+	}
+
+	// Rank error of the quantiles is small, and smallest in the tails.
+	for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+		x := h.Quantile(q)
+		rank := e.CDF(x)
+		tolerance := 0.01
+		if q < 0.05 || q > 0.95 {
+			tolerance = 0.002
+		}
+		if math.Abs(rank-q) > tolerance {
+			t.Errorf("Quantile(%v) %v has rank %v", q, x, rank)
+		}
+		if cdf := h.CDF(x); !approx(cdf, q) {
+			t.Errorf("CDF(Quantile(%v)) %v != %v", q, cdf, q)
+		}
+
+		lower, upper := h.CDFWithBounds(x)
+		if lower > rank || rank > upper {
+			t.Errorf("Exact CDF %v at %v outside bounds [%v, %v]", rank, x, lower, upper)
+		}
+		qlower, qupper := h.QuantileWithBounds(q)
+		if exact := e.Quantile(q); qlower[0] > exact[0] || exact[0] > qupper[0] {
+			t.Errorf("Quantile %v outside bounds [%v, %v]", exact, qlower, qupper)
+		}
+	}
+
+	if p := h.Probability([]float64{1}, []float64{math.Inf(1)}); math.Abs(p-0.5) > 0.01 {
+		t.Errorf("Probability above the median %v != 0.5", p)
+	}
+}
+
+func TestTDigestTails(t *testing.T) {
+	// With the same number of centroids and bins, the t-digest is more
+	// accurate in the tails.
+	r := rand.New(rand.NewSource(1))
+	d := NewTDigest(64)
+	h := NewHistogram(64, 1)
+	e := NewExactHistogram(1)
+	for i := 0; i < 5000; i++ {
+		v := []float64{math.Exp(r.NormFloat64() * 2)}
+		d.Add(v)
+		h.Add(v)
+		e.Add(v)
+	}
+
+	derr, herr := 0.0, 0.0
+	for _, q := range []float64{0.001, 0.005, 0.995, 0.999} {
+		derr += math.Abs(e.CDF(d.Quantile(q)) - q)
+		herr += math.Abs(e.CDF(h.Quantile(q)) - q)
+	}
+	t.Logf("Tail rank error: t-digest %.5f, histogram %.5f", derr, herr)
+	if derr > herr {
+		t.Errorf("Tail rank error of t-digest %v larger than histogram %v", derr, herr)
+	}
+}
+
+func TestTDigestMerge(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	a, b, all := NewTDigest(50), NewTDigest(50), NewTDigest(50)
+	e := NewExactHistogram(1)
+	for i := 0; i < 2000; i++ {
+		v := []float64{r.NormFloat64()}
+		if i%2 == 0 {
+			a.Add(v)
+		} else {
+			b.Add(v)
+		}
+		all.Add(v)
+		e.Add(v)
+	}
+
+	a.Merge(b)
+	if a.Count() != all.Count() || !approx(a.Mean()[0], all.Mean()[0]) || !approx(a.Variance()[0], all.Variance()[0]) {
+		t.Errorf("Merge mismatch %v %v %v != %v %v %v", a.Count(), a.Mean(), a.Variance(), all.Count(), all.Mean(), all.Variance())
+	}
+	for _, q := range []float64{0.01, 0.5, 0.99} {
+		if rank := e.CDF(a.Quantile(q)); math.Abs(rank-q) > 0.01 {
+			t.Errorf("Quantile(%v) of merged digest has rank %v", q, rank)
+		}
+	}
+
+	// Digests merge into native histograms and back.
+	h := NewHistogram(32, 1)
+	h.Merge(a)
+	if h.Count() != a.Count() || !approx(h.Mean()[0], a.Mean()[0]) {
+		t.Errorf("Merge into histogram mismatch %v %v != %v %v", h.Count(), h.Mean(), a.Count(), a.Mean())
+	}
+	c := NewTDigest(50)
+	c.Merge(h)
+	if c.Count() != h.Count() || !approx(c.Mean()[0], h.Mean()[0]) {
+		t.Errorf("Merge from histogram mismatch %v %v != %v %v", c.Count(), c.Mean(), h.Count(), h.Mean())
+	}
+	c.Merge(NewHistogram(32, 2))
+	if c.Count() != h.Count() {
+		t.Errorf("Merge of dimension 2 changed the count")
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.String() != a.String() || u.Quantile(0.9)[0] != a.Quantile(0.9)[0] {
+		t.Errorf("Centroids mismatch after Unmarshal")
+	}
+}
+
+func TestTDigestConcurrentReads(t *testing.T) {
+	h := NewTDigest(100)
+	for i := 0; i < 1000; i++ {
+		h.Add([]float64{float64(i)})
+	}
+
+	// The buffer holds points until the first read, which compresses it;
+	// concurrent readers must not race, run with -race.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if q := h.Quantile(0.5); math.Abs(q[0]-500) > 10 {
+				t.Errorf("Median mismatch %v", q)
+			}
+			if min, max := h.Min(), h.Max(); min[0] != 0 || max[0] != 999 {
+				t.Errorf("Range mismatch %v %v", min, max)
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := json.Marshal(h); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -54,6 +54,67 @@ func (b *bin) Merge(o bin) bin {
 	}
 }
 
+// clip returns the part of b within the box lo < x <= hi, assuming its points
+// are uniform within its min/max box. The count is scaled by the overlap, and
+// clipped dimensions get the mean and variance of the clipped range.
+func (b *bin) clip(lo, hi []float64) bin {
+	dimension := b.vec.Dimension()
+
+	count := b.count
+	for i := 0; i < dimension && count > 0; i++ {
+		count *= overlap(lo[i], hi[i], b.min.Value(i), b.max.Value(i))
+	}
+	if count <= 0 {
+		return bin{}
+	}
+
+	mean := make([]float64, dimension)
+	variance := make([]float64, dimension)
+	min := make([]float64, dimension)
+	max := make([]float64, dimension)
+
+	for i := 0; i < dimension; i++ {
+		mean[i], variance[i] = b.vec.Value(i), b.variance.Value(i)
+		min[i], max[i] = b.min.Value(i), b.max.Value(i)
+
+		if lo[i] > min[i] || hi[i] < max[i] {
+			min[i] = math.Max(lo[i], min[i])
+			max[i] = math.Min(hi[i], max[i])
+			mean[i] = (min[i] + max[i]) / 2
+			variance[i] = square(max[i]-min[i]) / 12
+		}
+	}
+
+	return bin{
+		vec:      NewVector(mean),
+		variance: NewVector(variance),
+		count:    count,
+		min:      NewVector(min),
+		max:      NewVector(max),
+	}
+}
+
+// project returns b with only the coordinates of dims, in that order.
+func (b *bin) project(dims []int) bin {
+	mean := make([]float64, len(dims))
+	variance := make([]float64, len(dims))
+	min := make([]float64, len(dims))
+	max := make([]float64, len(dims))
+
+	for i, j := range dims {
+		mean[i], variance[i] = b.vec.Value(j), b.variance.Value(j)
+		min[i], max[i] = b.min.Value(j), b.max.Value(j)
+	}
+
+	return bin{
+		vec:      NewVector(mean),
+		variance: NewVector(variance),
+		count:    b.count,
+		min:      NewVector(min),
+		max:      NewVector(max),
+	}
+}
+
 type vector struct {
 	values []float64
 }
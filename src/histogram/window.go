@@ -0,0 +1,60 @@
+package histogram
+
+import (
+	"sync"
+)
+
+// Window keeps one histogram per period for the last n periods, so queries
+// only reflect recent values. It is safe for concurrent use.
+type Window struct {
+	mu         sync.Mutex
+	histograms []Histogram
+	current    int
+	create     func() Histogram
+}
+
+// NewWindow returns a window of n periods, creating the histogram of every
+// period with create.
+func NewWindow(n int, create func() Histogram) *Window {
+	if n < 1 {
+		n = 1
+	}
+	w := &Window{histograms: make([]Histogram, n), create: create}
+	for i := range w.histograms {
+		w.histograms[i] = create()
+	}
+	return w
+}
+
+// Add adds values to the current period.
+func (w *Window) Add(values []float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.histograms[w.current].Add(values)
+}
+
+// Merge merges o into the current period.
+func (w *Window) Merge(o Histogram) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.histograms[w.current].Merge(o)
+}
+
+// Rotate starts a new period, dropping the oldest.
+func (w *Window) Rotate() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.current = (w.current + 1) % len(w.histograms)
+	w.histograms[w.current] = w.create()
+}
+
+// Snapshot returns a new histogram merging all periods of the window.
+func (w *Window) Snapshot() Histogram {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	r := w.create()
+	for _, h := range w.histograms {
+		r.Merge(h)
+	}
+	return r
+}